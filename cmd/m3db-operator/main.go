@@ -37,13 +37,20 @@ import (
 	"github.com/m3db/m3db-operator/pkg/controller"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+	"github.com/m3db/m3db-operator/pkg/webhook"
 
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/uber-go/tally"
 	promreporter "github.com/uber-go/tally/prometheus"
@@ -54,6 +61,10 @@ import (
 const (
 	// Informers will resync on this interval
 	_informerSyncDuration = 30 * time.Second
+
+	// Namespace of the leader election lock if neither the flag nor
+	// POD_NAMESPACE are set.
+	_defaultLeaderElectNamespace = "default"
 )
 
 var (
@@ -66,6 +77,21 @@ var (
 	_useProxy     bool
 	_debugLog     bool
 	_develLog     bool
+
+	_podReplacementGracePeriod  time.Duration
+	_podReplacementDeleteClaims bool
+	_dryRun                     bool
+
+	_leaderElect              bool
+	_leaderElectNamespace     string
+	_leaderElectName          string
+	_leaderElectLeaseDuration time.Duration
+	_leaderElectRenewDeadline time.Duration
+	_leaderElectRetryPeriod   time.Duration
+
+	_webhookListenAddress string
+	_webhookCertFile      string
+	_webhookKeyFile       string
 )
 
 func init() {
@@ -74,6 +100,18 @@ func init() {
 	flag.BoolVar(&_debugLog, "debug", false, "enable debug logging")
 	flag.BoolVar(&_develLog, "devel", false, "enable development logging mode")
 	flag.BoolVar(&_useProxy, "proxy", false, "use kubectl proxy for cluster communication")
+	flag.DurationVar(&_podReplacementGracePeriod, "pod-replacement-grace-period", 0, "how long a pod may be stuck on a dead node before it's deleted so it can be replaced; 0 disables")
+	flag.BoolVar(&_podReplacementDeleteClaims, "pod-replacement-delete-claims", false, "also delete the PersistentVolumeClaims of stuck pods that are replaced, permanently deleting their data")
+	flag.BoolVar(&_dryRun, "dry-run", false, "only plan changes to clusters, recording them in each cluster's status, instead of making them")
+	flag.BoolVar(&_leaderElect, "leader-elect", true, "elect a leader among operator replicas so that only one manages clusters at a time")
+	flag.StringVar(&_leaderElectNamespace, "leader-elect-namespace", "", "namespace of the leader election lock; defaults to $POD_NAMESPACE, or \"default\" if unset")
+	flag.StringVar(&_leaderElectName, "leader-elect-name", "m3db-operator", "name of the leader election lock")
+	flag.DurationVar(&_leaderElectLeaseDuration, "leader-elect-lease-duration", 15*time.Second, "duration standby replicas wait before attempting to take over leadership")
+	flag.DurationVar(&_leaderElectRenewDeadline, "leader-elect-renew-deadline", 10*time.Second, "duration the leader retries renewing its lease before giving up leadership")
+	flag.DurationVar(&_leaderElectRetryPeriod, "leader-elect-retry-period", 2*time.Second, "duration replicas wait between attempts to acquire or renew the lease")
+	flag.StringVar(&_webhookListenAddress, "webhook-listen-address", "", "address on which to serve the admission webhooks over TLS, e.g. \":8443\"; webhooks are disabled if empty")
+	flag.StringVar(&_webhookCertFile, "webhook-cert-file", "", "TLS certificate for the admission webhook server")
+	flag.StringVar(&_webhookKeyFile, "webhook-key-file", "", "TLS private key for the admission webhook server")
 	flag.Parse()
 }
 
@@ -126,6 +164,20 @@ func main() {
 		http.ListenAndServe(_metricsPort, nil)
 	}()
 
+	// Serve the admission webhooks. Every replica serves them, regardless of
+	// leadership.
+	if _webhookListenAddress != "" {
+		if _webhookCertFile == "" || _webhookKeyFile == "" {
+			logger.Fatal("webhook-cert-file and webhook-key-file must be set to serve webhooks")
+		}
+
+		webhookServer := webhook.NewServer(webhook.WithLogger(logger.With(zap.String("component", "webhook"))))
+		go func() {
+			err := http.ListenAndServeTLS(_webhookListenAddress, _webhookCertFile, _webhookKeyFile, webhookServer)
+			logger.Fatal("webhook server exited", zap.Error(err))
+		}()
+	}
+
 	// Create k8s clients
 	crdClient, kubeClient, kubeExt, err := newKubeClient(logger, _masterURL, _kubeCfgFile)
 	if err != nil {
@@ -167,6 +219,9 @@ func main() {
 		controller.WithCRDClient(crdClient),
 		controller.WithKubeClient(kubeClient),
 		controller.WithScope(scope),
+		controller.WithPodReplacementGracePeriod(_podReplacementGracePeriod),
+		controller.WithPodReplacementDeleteClaims(_podReplacementDeleteClaims),
+		controller.WithDryRun(_dryRun),
 	}
 
 	// Override coordinator addr (i.e. running out-of-cluster and port-forwarding)
@@ -200,11 +255,119 @@ func main() {
 		os.Exit(1)
 	}()
 
-	if err := controller.Run(2, stopCh); err != nil {
-		logger.Fatal("error running controller", zap.Error(err))
+	doneCh := make(chan struct{})
+	run := func(leaderStopCh <-chan struct{}) {
+		defer close(doneCh)
+		// The controller stops when the process is shut down or, if leader
+		// election is enabled, when leadership is lost.
+		if err := controller.Run(2, mergeStopChs(stopCh, leaderStopCh)); err != nil {
+			logger.Fatal("error running controller", zap.Error(err))
+		}
+	}
+
+	if !_leaderElect {
+		run(nil)
+		return
+	}
+
+	startedCh := make(chan struct{})
+	elector, err := newLeaderElector(logger, kubeClient, func(leaderStopCh <-chan struct{}) {
+		close(startedCh)
+		run(leaderStopCh)
+	})
+	if err != nil {
+		logger.Fatal("failed to create leader elector", zap.Error(err))
+	}
+
+	go elector.Run()
+
+	<-stopCh
+	select {
+	case <-startedCh:
+		// We're the leader, wait for the controller to shut down.
+		<-doneCh
+	default:
 	}
 }
 
+// mergeStopChs returns a channel that's closed once either a or b is closed. A
+// nil channel is never closed.
+func mergeStopChs(a, b <-chan struct{}) <-chan struct{} {
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		select {
+		case <-a:
+		case <-b:
+		}
+	}()
+	return merged
+}
+
+// newLeaderElector returns a leader elector which calls run once this replica
+// has acquired the lock. If the replica later loses the lock the process exits
+// so that it restarts as a standby.
+func newLeaderElector(
+	logger *zap.Logger,
+	kubeClient kubernetes.Interface,
+	run func(stopCh <-chan struct{}),
+) (*leaderelection.LeaderElector, error) {
+	id, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := _leaderElectNamespace
+	if namespace == "" {
+		namespace = os.Getenv("POD_NAMESPACE")
+	}
+	if namespace == "" {
+		namespace = _defaultLeaderElectNamespace
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(logger.Sugar().Infof)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: kubeClient.CoreV1().Events(namespace),
+	})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: _operatorName})
+
+	lock, err := resourcelock.New(resourcelock.ConfigMapsResourceLock,
+		namespace,
+		_leaderElectName,
+		kubeClient.CoreV1(),
+		resourcelock.ResourceLockConfig{
+			Identity:      id,
+			EventRecorder: recorder,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	electLogger := logger.With(
+		zap.String("identity", id),
+		zap.String("lock", namespace+"/"+_leaderElectName))
+
+	return leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: _leaderElectLeaseDuration,
+		RenewDeadline: _leaderElectRenewDeadline,
+		RetryPeriod:   _leaderElectRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(stopCh <-chan struct{}) {
+				electLogger.Info("acquired leadership, starting controller")
+				run(stopCh)
+			},
+			OnStoppedLeading: func() {
+				electLogger.Fatal("lost leadership")
+			},
+			OnNewLeader: func(leader string) {
+				electLogger.Info("observed new leader", zap.String("leader", leader))
+			},
+		},
+	})
+}
+
 func buildConfig(logger *zap.Logger, masterURL, kubeCfgFile string) (*rest.Config, error) {
 	if kubeCfgFile != "" {
 		logger.Info("using OutOfCluster k8s config", zap.String("kubeFile", kubeCfgFile))
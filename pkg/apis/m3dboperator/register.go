@@ -41,6 +41,24 @@ const (
 	// ResourcePlural is the plural form of custom resource kind
 	ResourcePlural = "m3dbclusters"
 
+	// ResourceShortName is the short name of the custom resource, e.g. for use
+	// with kubectl
+	ResourceShortName = "m3db"
+
+	// ResourceCategory is the category of resources the custom resource is
+	// listed with
+	ResourceCategory = "all"
+
+	// NamespaceResourceKind is the kind of the custom resource for namespaces
+	// that are managed separately from a cluster's spec
+	NamespaceResourceKind = "M3DBNamespace"
+
+	// NamespaceResourcePlural is the plural form of NamespaceResourceKind
+	NamespaceResourcePlural = "m3dbnamespaces"
+
+	// NamespaceResourceShortName is the short name of NamespaceResourceKind
+	NamespaceResourceShortName = "m3dbns"
+
 	// GroupName is the group that the custom resource belongs to
 	GroupName = "operator.m3db.io"
 
@@ -52,6 +70,9 @@ var (
 	// Name is the fully qualified name of the custom resource
 	Name = fmt.Sprintf("%s.%s", ResourcePlural, GroupName)
 
+	// NamespaceName is the fully qualified name of the namespace custom resource
+	NamespaceName = fmt.Sprintf("%s.%s", NamespaceResourcePlural, GroupName)
+
 	// SchemeGroupVersion is the schema version of the group
 	SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: Version}
 )
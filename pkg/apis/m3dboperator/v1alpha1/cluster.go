@@ -21,6 +21,8 @@
 package v1alpha1
 
 import (
+	"sort"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -43,6 +45,19 @@ const (
 
 	// ClusterConditionPodBootstrapping indicates there is a pod bootstrapping.
 	ClusterConditionPodBootstrapping ClusterConditionType = "PodBootstrapping"
+
+	// ClusterConditionNamespaceUpdateBlocked indicates the options of one or more
+	// namespaces in the spec differ from the cluster's in ways that can't be
+	// applied to an existing namespace.
+	ClusterConditionNamespaceUpdateBlocked ClusterConditionType = "NamespaceUpdateBlocked"
+
+	// ClusterConditionPaused indicates the operator is not making any changes to
+	// the cluster.
+	ClusterConditionPaused ClusterConditionType = "Paused"
+
+	// ClusterConditionDegraded indicates one or more instances have been
+	// bootstrapping for longer than expected.
+	ClusterConditionDegraded ClusterConditionType = "Degraded"
 )
 
 // +genclient
@@ -84,6 +99,36 @@ type M3DBStatus struct {
 	// observed. Kubernetes will automatically increment metadata.Generation every
 	// time the cluster spec is changed.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// PendingNamespaceDeletions lists the namespaces that have been removed from
+	// the spec and will be deleted once their grace period has passed.
+	PendingNamespaceDeletions []PendingNamespaceDeletion `json:"pendingNamespaceDeletions,omitempty"`
+
+	// PlannedActions lists, in order, the changes the operator would make to the
+	// cluster if it weren't in dry run mode.
+	PlannedActions []string `json:"plannedActions,omitempty"`
+
+	// BootstrappingInstances lists the placement instances that have
+	// initializing shards, along with when the operator first saw them
+	// bootstrapping.
+	BootstrappingInstances []BootstrappingInstance `json:"bootstrappingInstances,omitempty"`
+
+	// Instances is the total number of M3DB pods in the cluster's StatefulSets.
+	Instances int32 `json:"instances,omitempty"`
+
+	// LabelSelector selects the cluster's M3DB pods, in the serialized form
+	// used by the scale subresource.
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
+
+// BootstrappingInstance is a placement instance that has shards that are
+// still initializing.
+type BootstrappingInstance struct {
+	// ID is the instance's ID in the placement.
+	ID string `json:"id,omitempty"`
+
+	// Since is the time at which the instance was first seen bootstrapping.
+	Since string `json:"since,omitempty"`
 }
 
 func (s *M3DBStatus) hasConditionTrue(cond ClusterConditionType) bool {
@@ -181,6 +226,13 @@ type ClusterSpec struct {
 	// to deploy persistent volumes for data nodes
 	IsolationGroups []IsolationGroup `json:"isolationGroups,omitempty" yaml:"isolationGroups"`
 
+	// Replicas is the total number of instances in the cluster. If set, it's
+	// distributed evenly across the isolation groups and their NumInstances are
+	// ignored. It's set by scaling the cluster through its scale subresource,
+	// e.g. with kubectl scale.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty" yaml:"replicas"`
+
 	// Namespaces specifies the namespaces this cluster will hold.
 	Namespaces []Namespace `json:"namespaces,omitempty" yaml:"namespaces"`
 
@@ -208,6 +260,42 @@ type ClusterSpec struct {
 	// Labels sets the base labels that will be applied to resources created by
 	// the cluster. // TODO(schallert): design doc on labeling scheme.
 	Labels map[string]string `json:"labels,omitempty" yaml:"labels"`
+
+	// RetainEtcdDataOnDelete determines whether the cluster's placement and
+	// namespaces are left in etcd when the cluster is deleted. By default the
+	// operator deletes them. The data is never deleted when the cluster is
+	// deleted with orphan propagation.
+	// +optional
+	RetainEtcdDataOnDelete bool `json:"retainEtcdDataOnDelete,omitempty" yaml:"retainEtcdDataOnDelete"`
+
+	// NamespaceDeletionPolicy determines what happens to namespaces that are
+	// removed from the spec. By default they are retained, unless listed in the
+	// cluster's operator.m3db.io/delete-namespaces annotation.
+	// +optional
+	NamespaceDeletionPolicy NamespaceDeletionPolicy `json:"namespaceDeletionPolicy,omitempty" yaml:"namespaceDeletionPolicy"`
+
+	// NamespaceDeletionGracePeriodSeconds is how long the operator waits after a
+	// namespace is removed from the spec before deleting it. Restoring the
+	// namespace to the spec during this period cancels the deletion. Defaults to
+	// one hour.
+	// +optional
+	NamespaceDeletionGracePeriodSeconds *int64 `json:"namespaceDeletionGracePeriodSeconds,omitempty" yaml:"namespaceDeletionGracePeriodSeconds"`
+
+	// Paused stops the operator from making any changes to the cluster's
+	// StatefulSets, placement or namespaces, e.g. while it's being operated on
+	// manually. The cluster's status is still updated.
+	// +optional
+	Paused bool `json:"paused,omitempty" yaml:"paused"`
+
+	// ScalingPolicy configures how the operator changes the number of instances
+	// in an isolation group.
+	// +optional
+	ScalingPolicy ScalingPolicy `json:"scalingPolicy,omitempty" yaml:"scalingPolicy"`
+
+	// BootstrapTimeouts configures how long instances may bootstrap before the
+	// cluster is considered degraded or red.
+	// +optional
+	BootstrapTimeouts *BootstrapTimeouts `json:"bootstrapTimeouts,omitempty" yaml:"bootstrapTimeouts"`
 }
 
 // IsolationGroup defines the name of zone as well attributes for the zone configuration
@@ -219,6 +307,67 @@ type IsolationGroup struct {
 	NumInstances int32 `json:"numInstances,omitempty" yaml:"numInstances"`
 }
 
+// ScalingPolicy configures how the operator changes the number of instances in
+// an isolation group.
+type ScalingPolicy struct {
+	// BatchExpansion makes the operator scale a group straight to its desired
+	// number of instances and add all new instances in the cluster to the
+	// placement in a single operation, rather than adding one instance at a time.
+	// This reduces the total amount of data streamed between instances.
+	// +optional
+	BatchExpansion bool `json:"batchExpansion,omitempty" yaml:"batchExpansion"`
+
+	// MaxInstancesRemovedPerStep is the maximum number of instances the operator
+	// removes from an isolation group's placement, in a single placement
+	// change, and from its StatefulSet when shrinking the group. Defaults to 1.
+	// Instances are only ever removed from one isolation group at a time.
+	// +optional
+	MaxInstancesRemovedPerStep int32 `json:"maxInstancesRemovedPerStep,omitempty" yaml:"maxInstancesRemovedPerStep"`
+}
+
+// BootstrapTimeouts configures how long instances may bootstrap before the
+// operator reports them as stuck.
+type BootstrapTimeouts struct {
+	// DegradedAfterSeconds is how long an instance may bootstrap before the
+	// cluster's Degraded condition is set. Defaults to 30 minutes.
+	// +optional
+	DegradedAfterSeconds *int64 `json:"degradedAfterSeconds,omitempty" yaml:"degradedAfterSeconds"`
+
+	// RedAfterSeconds is how long an instance may bootstrap before the cluster's
+	// state is set to red. Defaults to 2 hours.
+	// +optional
+	RedAfterSeconds *int64 `json:"redAfterSeconds,omitempty" yaml:"redAfterSeconds"`
+}
+
+// DesiredIsolationGroups returns the cluster's isolation groups, in spec order,
+// with the number of instances the operator maintains in each of them. If
+// Replicas is set it's divided evenly between the groups, with the remaining
+// instances going to the groups whose names sort first.
+func (s ClusterSpec) DesiredIsolationGroups() []IsolationGroup {
+	groups := make([]IsolationGroup, len(s.IsolationGroups))
+	copy(groups, s.IsolationGroups)
+	if s.Replicas == nil || len(groups) == 0 {
+		return groups
+	}
+
+	byName := make([]*IsolationGroup, len(groups))
+	for i := range groups {
+		byName[i] = &groups[i]
+	}
+	sort.Slice(byName, func(i, j int) bool {
+		return byName[i].Name < byName[j].Name
+	})
+
+	numGroups := int32(len(groups))
+	for i, group := range byName {
+		group.NumInstances = *s.Replicas / numGroups
+		if int32(i) < *s.Replicas%numGroups {
+			group.NumInstances++
+		}
+	}
+	return groups
+}
+
 // GetByName fetches an IsolationGroup by name.
 func (g IsolationGroups) GetByName(name string) (IsolationGroup, bool) {
 	for _, group := range g {
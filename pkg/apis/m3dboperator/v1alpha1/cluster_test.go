@@ -157,3 +157,44 @@ func TestGetByName(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, a, g)
 }
+
+func TestDesiredIsolationGroups(t *testing.T) {
+	spec := ClusterSpec{
+		IsolationGroups: []IsolationGroup{
+			{Name: "c", NumInstances: 1},
+			{Name: "a", NumInstances: 1},
+			{Name: "b", NumInstances: 1},
+		},
+	}
+
+	assert.Equal(t, spec.IsolationGroups, spec.DesiredIsolationGroups())
+
+	for _, test := range []struct {
+		replicas int32
+		exp      []int32
+	}{
+		{replicas: 0, exp: []int32{0, 0, 0}},
+		{replicas: 3, exp: []int32{1, 1, 1}},
+		{replicas: 4, exp: []int32{1, 2, 1}},
+		{replicas: 5, exp: []int32{1, 2, 2}},
+		{replicas: 9, exp: []int32{3, 3, 3}},
+	} {
+		replicas := test.replicas
+		spec.Replicas = &replicas
+
+		groups := spec.DesiredIsolationGroups()
+		var (
+			names     []string
+			instances []int32
+		)
+		for _, g := range groups {
+			names = append(names, g.Name)
+			instances = append(instances, g.NumInstances)
+		}
+		assert.Equal(t, []string{"c", "a", "b"}, names)
+		assert.Equal(t, test.exp, instances, "replicas=%d", test.replicas)
+	}
+
+	// The spec's groups are not modified.
+	assert.Equal(t, int32(1), spec.IsolationGroups[0].NumInstances)
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespaceConditionType is a type of condition an M3DBNamespace can be in.
+type NamespaceConditionType string
+
+const (
+	// NamespaceConditionReady indicates that the namespace exists in its
+	// cluster with the options in its spec.
+	NamespaceConditionReady NamespaceConditionType = "Ready"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// M3DBNamespace defines a namespace of an M3DB cluster that is managed
+// separately from the cluster's spec.
+type M3DBNamespace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              NamespaceSpec   `json:"spec"`
+	Status            NamespaceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// M3DBNamespaceList represents a list of M3DB namespaces
+type M3DBNamespaceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []M3DBNamespace `json:"items"`
+}
+
+// NamespaceSpec defines the desired state of an M3DBNamespace.
+type NamespaceSpec struct {
+	// ClusterName is the name of the M3DBCluster, in the same Kubernetes
+	// namespace, that the namespace is created in.
+	ClusterName string `json:"clusterName"`
+
+	// Name is the name of the namespace in M3DB. Defaults to the name of the
+	// M3DBNamespace.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Preset indicates preset namespace options.
+	Preset string `json:"preset,omitempty"`
+
+	// Options points to optional custom namespace configuration.
+	// +optional
+	Options *NamespaceOptions `json:"options,omitempty"`
+}
+
+// NamespaceStatus contains the current state of an M3DBNamespace.
+type NamespaceStatus struct {
+	// ObservedGeneration is the generation of the spec that the operator last
+	// reconciled the namespace with.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions is a list of the conditions the namespace is in.
+	Conditions []NamespaceCondition `json:"conditions,omitempty"`
+}
+
+// NamespaceCondition represents various conditions an M3DBNamespace can be in.
+type NamespaceCondition struct {
+	// Type of namespace condition.
+	Type NamespaceConditionType `json:"type,omitempty"`
+
+	// Status of the condition (True, False, Unknown).
+	Status corev1.ConditionStatus `json:"status,omitempty"`
+
+	// Last time this condition was updated.
+	LastUpdateTime string `json:"lastUpdateTime,omitempty"`
+
+	// Last time this condition transitioned from one status to another.
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+
+	// Reason this condition last changed.
+	Reason string `json:"reason,omitempty"`
+
+	// Human-friendly message about this condition.
+	Message string `json:"message,omitempty"`
+}
+
+// NamespaceName returns the name of the namespace in M3DB.
+func (n *M3DBNamespace) NamespaceName() string {
+	if n.Spec.Name != "" {
+		return n.Spec.Name
+	}
+	return n.Name
+}
+
+// DesiredNamespace returns the M3DB namespace described by the spec.
+func (n *M3DBNamespace) DesiredNamespace() Namespace {
+	return Namespace{
+		Name:    n.NamespaceName(),
+		Preset:  n.Spec.Preset,
+		Options: n.Spec.Options,
+	}
+}
+
+// GetCondition returns the specified namespace condition if it exists with a
+// bool indicating whether it was found.
+func (s *NamespaceStatus) GetCondition(checkCond NamespaceConditionType) (NamespaceCondition, bool) {
+	for _, cond := range s.Conditions {
+		if cond.Type == checkCond {
+			return cond, true
+		}
+	}
+	return NamespaceCondition{}, false
+}
+
+// UpdateCondition updates one of the status's conditions, replacing the state
+// of cond.Type if it exists or adding the condition if it doesn't exist.
+func (s *NamespaceStatus) UpdateCondition(newCond NamespaceCondition) {
+	for i, cond := range s.Conditions {
+		if cond.Type == newCond.Type {
+			s.Conditions[i] = newCond
+			return
+		}
+	}
+
+	s.Conditions = append(s.Conditions, newCond)
+}
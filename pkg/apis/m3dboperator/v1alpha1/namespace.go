@@ -35,6 +35,29 @@ type Namespace struct {
 	Options *NamespaceOptions `json:"options,omitempty"`
 }
 
+// NamespaceDeletionPolicy determines whether namespaces removed from a
+// cluster's spec are deleted.
+type NamespaceDeletionPolicy string
+
+const (
+	// NamespaceDeletionPolicyRetain leaves namespaces removed from the spec in
+	// the cluster. This is the default.
+	NamespaceDeletionPolicyRetain NamespaceDeletionPolicy = "Retain"
+
+	// NamespaceDeletionPolicyDelete deletes namespaces removed from the spec once
+	// the cluster's namespace deletion grace period has passed.
+	NamespaceDeletionPolicyDelete NamespaceDeletionPolicy = "Delete"
+)
+
+// PendingNamespaceDeletion is a namespace that will be deleted from the cluster.
+type PendingNamespaceDeletion struct {
+	// Name is the namespace name.
+	Name string `json:"name,omitempty"`
+
+	// DeleteAfter is the time after which the namespace will be deleted.
+	DeleteAfter string `json:"deleteAfter,omitempty"`
+}
+
 // RetentionOptions defines parameters for data retention.
 type RetentionOptions struct {
 	// RetentionPeriod controls how long data for the namespace is retained.
@@ -40,6 +40,23 @@ const (
 	PodIdentitySourceNodeName PodIdentitySource = "NodeName"
 )
 
+// PodIdentityChangePolicy determines what the operator does when the identity
+// derived for a pod no longer matches the identity the pod is annotated with,
+// e.g. because the cluster's pod identity sources changed.
+type PodIdentityChangePolicy string
+
+const (
+	// PodIdentityChangePolicyIgnore only logs the mismatch, and the pod keeps
+	// its original identity. This is the default.
+	PodIdentityChangePolicyIgnore PodIdentityChangePolicy = "Ignore"
+
+	// PodIdentityChangePolicyReplace replaces the pod's instance in the
+	// placement with an instance for its new identity, updates the pod's
+	// identity annotation and, once the placement change is accepted, restarts
+	// the pod so it uses its new identity.
+	PodIdentityChangePolicyReplace PodIdentityChangePolicy = "Replace"
+)
+
 // PodIdentity contains all the fields that may be used to identify a pod's
 // identity in the M3DB placement. Any non-empty fields will be used to identity
 // uniqueness of a pod for the purpose of M3DB replace operations.
@@ -58,4 +75,9 @@ type PodIdentityConfig struct {
 	// a pod's name will always be used. If empty, defaults to pod name and
 	// UID.
 	Sources []PodIdentitySource `json:"sources"`
+
+	// ChangePolicy determines what happens when a pod's identity changes. If
+	// empty, defaults to Ignore.
+	// +optional
+	ChangePolicy PodIdentityChangePolicy `json:"changePolicy,omitempty"`
 }
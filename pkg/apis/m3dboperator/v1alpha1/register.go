@@ -59,6 +59,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&M3DBCluster{},
 		&M3DBClusterList{},
+		&M3DBNamespace{},
+		&M3DBNamespaceList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -29,6 +29,48 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapTimeouts) DeepCopyInto(out *BootstrapTimeouts) {
+	*out = *in
+	if in.DegradedAfterSeconds != nil {
+		in, out := &in.DegradedAfterSeconds, &out.DegradedAfterSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RedAfterSeconds != nil {
+		in, out := &in.RedAfterSeconds, &out.RedAfterSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapTimeouts.
+func (in *BootstrapTimeouts) DeepCopy() *BootstrapTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrappingInstance) DeepCopyInto(out *BootstrappingInstance) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrappingInstance.
+func (in *BootstrappingInstance) DeepCopy() *BootstrappingInstance {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrappingInstance)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterCondition) DeepCopyInto(out *ClusterCondition) {
 	*out = *in
@@ -53,6 +95,11 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = make([]IsolationGroup, len(*in))
 		copy(*out, *in)
 	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
 	if in.Namespaces != nil {
 		in, out := &in.Namespaces, &out.Namespaces
 		*out = make([]Namespace, len(*in))
@@ -83,6 +130,16 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.NamespaceDeletionGracePeriodSeconds != nil {
+		in, out := &in.NamespaceDeletionGracePeriodSeconds, &out.NamespaceDeletionGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.BootstrapTimeouts != nil {
+		in, out := &in.BootstrapTimeouts, &out.BootstrapTimeouts
+		*out = new(BootstrapTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -209,6 +266,67 @@ func (in *M3DBClusterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *M3DBNamespace) DeepCopyInto(out *M3DBNamespace) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new M3DBNamespace.
+func (in *M3DBNamespace) DeepCopy() *M3DBNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(M3DBNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *M3DBNamespace) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *M3DBNamespaceList) DeepCopyInto(out *M3DBNamespaceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]M3DBNamespace, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new M3DBNamespaceList.
+func (in *M3DBNamespaceList) DeepCopy() *M3DBNamespaceList {
+	if in == nil {
+		return nil
+	}
+	out := new(M3DBNamespaceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *M3DBNamespaceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *M3DBStatus) DeepCopyInto(out *M3DBStatus) {
 	*out = *in
@@ -217,6 +335,21 @@ func (in *M3DBStatus) DeepCopyInto(out *M3DBStatus) {
 		*out = make([]ClusterCondition, len(*in))
 		copy(*out, *in)
 	}
+	if in.PendingNamespaceDeletions != nil {
+		in, out := &in.PendingNamespaceDeletions, &out.PendingNamespaceDeletions
+		*out = make([]PendingNamespaceDeletion, len(*in))
+		copy(*out, *in)
+	}
+	if in.PlannedActions != nil {
+		in, out := &in.PlannedActions, &out.PlannedActions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BootstrappingInstances != nil {
+		in, out := &in.BootstrappingInstances, &out.BootstrappingInstances
+		*out = make([]BootstrappingInstance, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -251,6 +384,22 @@ func (in *Namespace) DeepCopy() *Namespace {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceCondition) DeepCopyInto(out *NamespaceCondition) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceCondition.
+func (in *NamespaceCondition) DeepCopy() *NamespaceCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespaceOptions) DeepCopyInto(out *NamespaceOptions) {
 	*out = *in
@@ -269,6 +418,64 @@ func (in *NamespaceOptions) DeepCopy() *NamespaceOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceSpec) DeepCopyInto(out *NamespaceSpec) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = new(NamespaceOptions)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceSpec.
+func (in *NamespaceSpec) DeepCopy() *NamespaceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceStatus) DeepCopyInto(out *NamespaceStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]NamespaceCondition, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceStatus.
+func (in *NamespaceStatus) DeepCopy() *NamespaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingNamespaceDeletion) DeepCopyInto(out *PendingNamespaceDeletion) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingNamespaceDeletion.
+func (in *PendingNamespaceDeletion) DeepCopy() *PendingNamespaceDeletion {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingNamespaceDeletion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodIdentity) DeepCopyInto(out *PodIdentity) {
 	*out = *in
@@ -321,3 +528,19 @@ func (in *RetentionOptions) DeepCopy() *RetentionOptions {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingPolicy) DeepCopyInto(out *ScalingPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingPolicy.
+func (in *ScalingPolicy) DeepCopy() *ScalingPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalingPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -36,6 +36,10 @@ func (c *FakeOperatorV1alpha1) M3DBClusters(namespace string) v1alpha1.M3DBClust
 	return &FakeM3DBClusters{c, namespace}
 }
 
+func (c *FakeOperatorV1alpha1) M3DBNamespaces(namespace string) v1alpha1.M3DBNamespaceInterface {
+	return &FakeM3DBNamespaces{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeOperatorV1alpha1) RESTClient() rest.Interface {
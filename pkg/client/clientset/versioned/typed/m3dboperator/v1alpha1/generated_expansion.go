@@ -23,3 +23,5 @@
 package v1alpha1
 
 type M3DBClusterExpansion interface{}
+
+type M3DBNamespaceExpansion interface{}
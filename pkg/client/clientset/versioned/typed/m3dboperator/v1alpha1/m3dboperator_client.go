@@ -32,6 +32,7 @@ import (
 type OperatorV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	M3DBClustersGetter
+	M3DBNamespacesGetter
 }
 
 // OperatorV1alpha1Client is used to interact with features provided by the operator.m3db.io group.
@@ -43,6 +44,10 @@ func (c *OperatorV1alpha1Client) M3DBClusters(namespace string) M3DBClusterInter
 	return newM3DBClusters(c, namespace)
 }
 
+func (c *OperatorV1alpha1Client) M3DBNamespaces(namespace string) M3DBNamespaceInterface {
+	return newM3DBNamespaces(c, namespace)
+}
+
 // NewForConfig creates a new OperatorV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*OperatorV1alpha1Client, error) {
 	config := *c
@@ -59,6 +59,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=operator.m3db.io, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("m3dbclusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Operator().V1alpha1().M3DBClusters().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("m3dbnamespaces"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Operator().V1alpha1().M3DBNamespaces().Informer()}, nil
 
 	}
 
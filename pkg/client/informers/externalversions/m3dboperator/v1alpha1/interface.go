@@ -30,6 +30,8 @@ import (
 type Interface interface {
 	// M3DBClusters returns a M3DBClusterInformer.
 	M3DBClusters() M3DBClusterInformer
+	// M3DBNamespaces returns a M3DBNamespaceInformer.
+	M3DBNamespaces() M3DBNamespaceInformer
 }
 
 type version struct {
@@ -47,3 +49,8 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) M3DBClusters() M3DBClusterInformer {
 	return &m3DBClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// M3DBNamespaces returns a M3DBNamespaceInformer.
+func (v *version) M3DBNamespaces() M3DBNamespaceInformer {
+	return &m3DBNamespaceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -29,3 +29,11 @@ type M3DBClusterListerExpansion interface{}
 // M3DBClusterNamespaceListerExpansion allows custom methods to be added to
 // M3DBClusterNamespaceLister.
 type M3DBClusterNamespaceListerExpansion interface{}
+
+// M3DBNamespaceListerExpansion allows custom methods to be added to
+// M3DBNamespaceLister.
+type M3DBNamespaceListerExpansion interface{}
+
+// M3DBNamespaceNamespaceListerExpansion allows custom methods to be added to
+// M3DBNamespaceNamespaceLister.
+type M3DBNamespaceNamespaceListerExpansion interface{}
@@ -55,7 +55,7 @@ func (c *Controller) EnsurePlacement(cluster *myspec.M3DBCluster) error {
 	_, err := plClient.Get()
 	if err == m3admin.ErrNotFound {
 		placementInitRequest := &admin.PlacementInitRequest{
-			NumShards:         cluster.Spec.NumberOfShards,
+			NumShards:         k8sops.NumberOfShards(cluster),
 			ReplicationFactor: cluster.Spec.ReplicationFactor,
 		}
 		placementDetails, err := c.k8sclient.GetPlacementDetails(cluster)
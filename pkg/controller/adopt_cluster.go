@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"go.uber.org/zap"
+)
+
+// orphanedStatefulSets returns the StatefulSets labeled as belonging to the
+// cluster and one of its isolation groups that have no controller, e.g.
+// because the cluster was deleted with orphan propagation and recreated. The
+// sets are sorted by name.
+func (c *Controller) orphanedStatefulSets(cluster *myspec.M3DBCluster) ([]*appsv1.StatefulSet, error) {
+	selector := klabels.SelectorFromSet(klabels.Set{labels.Cluster: cluster.Name})
+	sets, err := c.statefulSetLister.StatefulSets(cluster.Namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("error listing statefulsets: %v", err)
+	}
+
+	var orphans []*appsv1.StatefulSet
+	for _, set := range sets {
+		if metav1.GetControllerOf(set) != nil {
+			continue
+		}
+
+		group, ok := set.Labels[labels.IsolationGroup]
+		if !ok {
+			continue
+		}
+
+		if _, ok := myspec.IsolationGroups(cluster.Spec.IsolationGroups).GetByName(group); !ok {
+			c.logger.Warn("not adopting statefulset of isolation group not in cluster spec",
+				zap.String("statefulSet", set.Name),
+				zap.String("isolationGroup", group))
+			continue
+		}
+
+		orphans = append(orphans, set.DeepCopy())
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].Name < orphans[j].Name
+	})
+	return orphans, nil
+}
+
+// adoptOrphanedStatefulSets makes the cluster the controller of any of its
+// orphaned StatefulSets. The sets' pods are adopted by the sets themselves.
+// Returns true if any set was adopted, in which case no other changes should
+// be made until the adoption is reflected in the cache.
+func (c *Controller) adoptOrphanedStatefulSets(cluster *myspec.M3DBCluster) (bool, error) {
+	orphans, err := c.orphanedStatefulSets(cluster)
+	if err != nil {
+		return false, err
+	}
+
+	for _, set := range orphans {
+		set.OwnerReferences = append(set.OwnerReferences, *k8sops.GenerateOwnerRef(cluster))
+		if _, err := c.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set); err != nil {
+			c.recorder.WarningEvent(cluster, eventer.ReasonFailedToAdopt, "failed to adopt statefulset %s: %v", set.Name, err)
+			return false, fmt.Errorf("error adopting statefulset %s: %v", set.Name, err)
+		}
+
+		c.logger.Info("adopted orphaned statefulset",
+			zap.String("cluster", cluster.Name),
+			zap.String("statefulSet", set.Name))
+		c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulAdopt, "adopted orphaned statefulset %s", set.Name)
+	}
+
+	return len(orphans) > 0, nil
+}
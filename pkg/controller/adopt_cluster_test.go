@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package controller
+
+import (
+	"testing"
+
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdoptOrphanedStatefulSets(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.UID = types.UID("new-uid")
+
+	newSet := func(group string) *appsv1.StatefulSet {
+		set, err := k8sops.GenerateStatefulSet(cluster, group, 3)
+		require.NoError(t, err)
+		set.Namespace = cluster.Namespace
+		return set
+	}
+
+	// Orphaned sets of the cluster's groups are adopted.
+	orphanA := newSet("us-fake1-a")
+	orphanA.OwnerReferences = nil
+	orphanB := newSet("us-fake1-b")
+	orphanB.OwnerReferences = nil
+
+	// Owned sets are left alone.
+	owned := newSet("us-fake1-c")
+
+	// Sets of groups not in the spec, or of other clusters, are left alone.
+	unknownGroup := newSet("us-fake1-a")
+	unknownGroup.Name = "cluster-zones-us-fake1-z"
+	unknownGroup.Labels[labels.IsolationGroup] = "us-fake1-z"
+	unknownGroup.OwnerReferences = nil
+	otherCluster := newSet("us-fake1-a")
+	otherCluster.Name = "other-us-fake1-a"
+	otherCluster.Labels[labels.Cluster] = "other"
+	otherCluster.OwnerReferences = nil
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{orphanA, orphanB, owned, unknownGroup, otherCluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	orphans, err := controller.orphanedStatefulSets(cluster)
+	require.NoError(t, err)
+	require.Len(t, orphans, 2)
+	assert.Equal(t, orphanA.Name, orphans[0].Name)
+	assert.Equal(t, orphanB.Name, orphans[1].Name)
+
+	adopted, err := controller.adoptOrphanedStatefulSets(cluster)
+	require.NoError(t, err)
+	assert.True(t, adopted)
+
+	for _, name := range []string{orphanA.Name, orphanB.Name} {
+		set, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.True(t, metav1.IsControlledBy(set, cluster), "set %s should be adopted", name)
+	}
+
+	for _, name := range []string{unknownGroup.Name, otherCluster.Name} {
+		set, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Nil(t, metav1.GetControllerOf(set), "set %s should not be adopted", name)
+	}
+}
+
+func TestAdoptOrphanedStatefulSetsNone(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	require.NoError(t, err)
+	set.Namespace = cluster.Namespace
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{set},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	adopted, err := controller.adoptOrphanedStatefulSets(cluster)
+	require.NoError(t, err)
+	assert.False(t, adopted)
+}
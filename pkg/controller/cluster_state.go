@@ -0,0 +1,443 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	healthyClusterMessage = "all instances are available"
+
+	defaultBootstrapDegradedTimeout = 30 * time.Minute
+	defaultBootstrapRedTimeout      = 2 * time.Hour
+)
+
+// clusterState derives the health of a cluster from its placement and
+// statefulsets. The cluster is red if any shard has lost a majority of its
+// replicas, yellow if any instance or pod is unavailable, and green otherwise.
+// The placement may be nil if it could not be fetched.
+func clusterState(
+	cluster *myspec.M3DBCluster,
+	pl placement.Placement,
+	sets []*appsv1.StatefulSet,
+) (myspec.M3DBState, string) {
+	var (
+		state    = myspec.GreenState
+		problems []string
+	)
+
+	if numGroups := len(cluster.Spec.IsolationGroups); len(sets) < numGroups {
+		state = myspec.YellowState
+		problems = append(problems, fmt.Sprintf("%d/%d statefulsets created", len(sets), numGroups))
+	}
+
+	sortedSets := make([]*appsv1.StatefulSet, len(sets))
+	copy(sortedSets, sets)
+	sort.Slice(sortedSets, func(i, j int) bool {
+		return sortedSets[i].Name < sortedSets[j].Name
+	})
+
+	for _, set := range sortedSets {
+		if set.Spec.Replicas == nil || set.Status.ReadyReplicas >= *set.Spec.Replicas {
+			continue
+		}
+
+		state = myspec.YellowState
+		problems = append(problems, fmt.Sprintf("statefulset %s has %d/%d ready pods",
+			set.Name, set.Status.ReadyReplicas, *set.Spec.Replicas))
+	}
+
+	if pl == nil {
+		state = myspec.YellowState
+		if cluster.Status.HasInitializedPlacement() {
+			problems = append(problems, "placement unavailable")
+		} else {
+			problems = append(problems, "placement not initialized")
+		}
+		return state, strings.Join(problems, "; ")
+	}
+
+	var (
+		unavailableInsts  int
+		availableReplicas = make(map[uint32]int)
+	)
+
+	for _, inst := range pl.Instances() {
+		if !inst.IsAvailable() {
+			unavailableInsts++
+		}
+
+		for _, s := range inst.Shards().All() {
+			if s.State() == shard.Available {
+				availableReplicas[s.ID()]++
+			} else if _, ok := availableReplicas[s.ID()]; !ok {
+				availableReplicas[s.ID()] = 0
+			}
+		}
+	}
+
+	rf := int(cluster.Spec.ReplicationFactor)
+	quorum := rf/2 + 1
+
+	var quorumLost, underReplicated int
+	for _, n := range availableReplicas {
+		if n < quorum {
+			quorumLost++
+		} else if n < rf {
+			underReplicated++
+		}
+	}
+
+	if unavailableInsts > 0 {
+		state = myspec.YellowState
+		problems = append(problems, fmt.Sprintf("%d/%d instances unavailable", unavailableInsts, pl.NumInstances()))
+	}
+
+	if underReplicated > 0 {
+		state = myspec.YellowState
+		problems = append(problems, fmt.Sprintf("%d shards have fewer than %d available replicas", underReplicated, rf))
+	}
+
+	if quorumLost > 0 {
+		state = myspec.RedState
+		problems = append(problems, fmt.Sprintf("%d shards have fewer than %d available replicas", quorumLost, quorum))
+	}
+
+	if len(problems) == 0 {
+		return state, healthyClusterMessage
+	}
+
+	return state, strings.Join(problems, "; ")
+}
+
+// updateClusterState computes the cluster's state and records it, along with
+// the observed generation, the number of instances and their selector, and the
+// instances that are bootstrapping, in the cluster's status if it has changed.
+// Instances that have been bootstrapping for longer than the cluster's
+// bootstrap timeouts mark the cluster as degraded, and eventually red.
+func (c *Controller) updateClusterState(
+	cluster *myspec.M3DBCluster,
+	pl placement.Placement,
+	sets []*appsv1.StatefulSet,
+) (*myspec.M3DBCluster, error) {
+	state, message := clusterState(cluster, pl, sets)
+
+	now := c.clock.Now()
+	bootstrapping := cluster.Status.BootstrappingInstances
+	if pl != nil {
+		var finished map[string]time.Duration
+		bootstrapping, finished = trackBootstrappingInstances(bootstrapping, pl, now)
+		c.reportBootstrapDurations(cluster, pl, bootstrapping, finished, now)
+	}
+
+	degradedAfter, redAfter := bootstrapTimeouts(cluster)
+	if red := stuckBootstraps(bootstrapping, now, redAfter); len(red) > 0 {
+		state = myspec.RedState
+		message = fmt.Sprintf("%s; %d instances bootstrapping for longer than %s", message, len(red), redAfter)
+	}
+
+	cluster, err := c.setDegradedStatus(cluster, stuckBootstraps(bootstrapping, now, degradedAfter), degradedAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing else may trigger a sync of the cluster when a bootstrapping
+	// instance times out, so check back then.
+	if after, ok := nextBootstrapTimeout(bootstrapping, now, degradedAfter, redAfter); ok {
+		if key, err := cache.MetaNamespaceKeyFunc(cluster); err == nil {
+			c.clusterWorkQueue.AddAfter(key, after)
+		}
+	}
+
+	instances := numInstances(sets)
+	selector := podSelector(cluster)
+
+	status := cluster.Status
+	if status.State == state &&
+		status.Message == message &&
+		status.ObservedGeneration == cluster.Generation &&
+		status.Instances == instances &&
+		status.LabelSelector == selector &&
+		reflect.DeepEqual(status.BootstrappingInstances, bootstrapping) {
+		return cluster, nil
+	}
+
+	if status.State != state {
+		c.logger.Info("cluster state changed",
+			zap.String("cluster", cluster.Name),
+			zap.String("oldState", string(status.State)),
+			zap.String("newState", string(state)),
+			zap.String("message", message))
+	}
+
+	generation := cluster.Generation
+	cluster, err = c.updateStatus(cluster, func(status *myspec.M3DBStatus) {
+		status.State = state
+		status.Message = message
+		status.ObservedGeneration = generation
+		status.BootstrappingInstances = bootstrapping
+		status.Instances = instances
+		status.LabelSelector = selector
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating cluster state: %v", err)
+	}
+
+	return cluster, nil
+}
+
+// numInstances returns the total number of pods in a cluster's StatefulSets.
+func numInstances(sets []*appsv1.StatefulSet) int32 {
+	var n int32
+	for _, set := range sets {
+		n += set.Status.Replicas
+	}
+	return n
+}
+
+// podSelector returns the serialized label selector of a cluster's M3DB pods.
+func podSelector(cluster *myspec.M3DBCluster) string {
+	return klabels.SelectorFromSet(klabels.Set{
+		labels.App:       labels.AppM3DB,
+		labels.Cluster:   cluster.Name,
+		labels.Component: labels.ComponentM3DBNode,
+	}).String()
+}
+
+// trackBootstrappingInstances returns the instances of the placement that
+// are bootstrapping, i.e. have initializing shards, sorted by ID. Instances
+// that were already bootstrapping in prev keep the time they were first seen,
+// and new ones are seen at now. Also returns how long each instance in prev
+// that has since finished bootstrapping took to bootstrap.
+func trackBootstrappingInstances(
+	prev []myspec.BootstrappingInstance,
+	pl placement.Placement,
+	now time.Time,
+) ([]myspec.BootstrappingInstance, map[string]time.Duration) {
+	since := make(map[string]string, len(prev))
+	for _, inst := range prev {
+		since[inst.ID] = inst.Since
+	}
+
+	var (
+		bootstrapping []myspec.BootstrappingInstance
+		finished      = make(map[string]time.Duration)
+	)
+	for _, inst := range pl.Instances() {
+		seen, ok := since[inst.ID()]
+		if inst.Shards().NumShardsForState(shard.Initializing) == 0 {
+			if ok {
+				if t, err := time.Parse(time.RFC3339, seen); err == nil {
+					finished[inst.ID()] = now.Sub(t)
+				}
+			}
+			continue
+		}
+
+		if !ok {
+			seen = now.UTC().Format(time.RFC3339)
+		}
+		bootstrapping = append(bootstrapping, myspec.BootstrappingInstance{
+			ID:    inst.ID(),
+			Since: seen,
+		})
+	}
+
+	sort.Slice(bootstrapping, func(i, j int) bool {
+		return bootstrapping[i].ID < bootstrapping[j].ID
+	})
+	return bootstrapping, finished
+}
+
+// stuckBootstraps returns the IDs of the instances that have been
+// bootstrapping for longer than timeout.
+func stuckBootstraps(insts []myspec.BootstrappingInstance, now time.Time, timeout time.Duration) []string {
+	var stuck []string
+	for _, inst := range insts {
+		since, err := time.Parse(time.RFC3339, inst.Since)
+		if err != nil {
+			continue
+		}
+		if now.Sub(since) > timeout {
+			stuck = append(stuck, inst.ID)
+		}
+	}
+	return stuck
+}
+
+// nextBootstrapTimeout returns how long until the first of the bootstrapping
+// instances exceeds one of the timeouts it hasn't already exceeded. Returns
+// false if there's no such instance.
+func nextBootstrapTimeout(
+	insts []myspec.BootstrappingInstance,
+	now time.Time,
+	timeouts ...time.Duration,
+) (time.Duration, bool) {
+	var (
+		next  time.Duration
+		found bool
+	)
+	for _, inst := range insts {
+		since, err := time.Parse(time.RFC3339, inst.Since)
+		if err != nil {
+			continue
+		}
+		for _, timeout := range timeouts {
+			// Instances are stuck once they've bootstrapped for longer than
+			// the timeout, and their start is only known to the second.
+			after := since.Add(timeout).Sub(now) + time.Second
+			if after <= 0 {
+				continue
+			}
+			if !found || after < next {
+				next, found = after, true
+			}
+		}
+	}
+	return next, found
+}
+
+// bootstrapTimeouts returns how long instances may bootstrap before the
+// cluster is degraded, and before it is red.
+func bootstrapTimeouts(cluster *myspec.M3DBCluster) (time.Duration, time.Duration) {
+	degraded, red := defaultBootstrapDegradedTimeout, defaultBootstrapRedTimeout
+	if timeouts := cluster.Spec.BootstrapTimeouts; timeouts != nil {
+		if secs := timeouts.DegradedAfterSeconds; secs != nil {
+			degraded = time.Duration(*secs) * time.Second
+		}
+		if secs := timeouts.RedAfterSeconds; secs != nil {
+			red = time.Duration(*secs) * time.Second
+		}
+	}
+	return degraded, red
+}
+
+// setDegradedStatus records in the cluster's Degraded condition whether any
+// instances have been bootstrapping for too long. The condition is only
+// written when it changes, and isn't added to clusters that have never been
+// degraded.
+func (c *Controller) setDegradedStatus(
+	cluster *myspec.M3DBCluster,
+	stuck []string,
+	timeout time.Duration,
+) (*myspec.M3DBCluster, error) {
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionDegraded)
+	degraded := ok && cond.Status == corev1.ConditionTrue
+	if !degraded && len(stuck) == 0 {
+		return cluster, nil
+	}
+
+	var (
+		status  = corev1.ConditionFalse
+		reason  = "BootstrapsCompleted"
+		message = "no instances have been bootstrapping for too long"
+	)
+	if len(stuck) > 0 {
+		status = corev1.ConditionTrue
+		reason = "BootstrapTimeout"
+		message = fmt.Sprintf("instances bootstrapping for longer than %s: %s", timeout, strings.Join(stuck, ", "))
+	}
+
+	if ok && cond.Status == status && cond.Message == message {
+		return cluster, nil
+	}
+
+	if status == corev1.ConditionTrue && !degraded {
+		c.logger.Warn("cluster degraded", zap.String("cluster", cluster.Name), zap.Strings("instances", stuck))
+	}
+
+	updated, err := c.setStatus(cluster, myspec.ClusterConditionDegraded, status, reason, message)
+	if err != nil {
+		return nil, fmt.Errorf("error updating degraded status: %v", err)
+	}
+
+	return updated, nil
+}
+
+// reportBootstrapDurations reports how long each instance has been
+// bootstrapping, and how long each instance that finished bootstrapping took.
+func (c *Controller) reportBootstrapDurations(
+	cluster *myspec.M3DBCluster,
+	pl placement.Placement,
+	bootstrapping []myspec.BootstrappingInstance,
+	finished map[string]time.Duration,
+	now time.Time,
+) {
+	instanceScope := func(id string) tally.Scope {
+		name := id
+		if inst, ok := pl.Instance(id); ok && inst.Hostname() != "" {
+			name = strings.Split(inst.Hostname(), ".")[0]
+		}
+		return c.scope.Tagged(map[string]string{
+			"cluster":  cluster.Name,
+			"instance": name,
+		})
+	}
+
+	for _, inst := range bootstrapping {
+		since, err := time.Parse(time.RFC3339, inst.Since)
+		if err != nil {
+			continue
+		}
+		instanceScope(inst.ID).Gauge("instance_bootstrapping_seconds").Update(now.Sub(since).Seconds())
+	}
+
+	for id, d := range finished {
+		scope := instanceScope(id)
+		scope.Gauge("instance_bootstrapping_seconds").Update(0)
+		scope.Timer("instance_bootstrap_duration").Record(d)
+	}
+}
+
+// activePlacement returns the cluster's placement, or nil if the placement
+// hasn't been initialized or can't be fetched.
+func (c *Controller) activePlacement(cluster *myspec.M3DBCluster) placement.Placement {
+	if !cluster.Status.HasInitializedPlacement() {
+		return nil
+	}
+
+	pl, err := c.adminClient.placementClientForCluster(cluster).Get()
+	if err != nil {
+		c.logger.Warn("error fetching placement", zap.String("cluster", cluster.Name), zap.Error(err))
+		return nil
+	}
+
+	return pl
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/kubernetes/utils/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+// newStatePlacement returns a placement with one instance per entry in
+// states, each owning shards 0 and 1 in the given state.
+func newStatePlacement(states ...shard.State) placement.Placement {
+	insts := make([]placement.Instance, len(states))
+	for i, state := range states {
+		insts[i] = placement.NewInstance().
+			SetID(strconv.Itoa(i)).
+			SetShards(shard.NewShards([]shard.Shard{
+				shard.NewShard(0).SetState(state),
+				shard.NewShard(1).SetState(state),
+			}))
+	}
+	return placement.NewPlacement().SetInstances(insts)
+}
+
+func readySets(t *testing.T, cluster *myspec.M3DBCluster) []*appsv1.StatefulSet {
+	var sets []*appsv1.StatefulSet
+	for _, group := range cluster.Spec.IsolationGroups {
+		set, err := k8sops.GenerateStatefulSet(cluster, group.Name, group.NumInstances)
+		require.NoError(t, err)
+		set.Status.Replicas = group.NumInstances
+		set.Status.ReadyReplicas = group.NumInstances
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+func TestClusterState(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Status.UpdateCondition(myspec.ClusterCondition{
+		Type:   myspec.ClusterConditionPlacementInitialized,
+		Status: "True",
+	})
+
+	for _, test := range []struct {
+		name     string
+		pl       placement.Placement
+		sets     func() []*appsv1.StatefulSet
+		expState myspec.M3DBState
+		expMsg   string
+	}{
+		{
+			name:     "healthy",
+			pl:       newStatePlacement(shard.Available, shard.Available, shard.Available),
+			sets:     func() []*appsv1.StatefulSet { return readySets(t, cluster) },
+			expState: myspec.GreenState,
+			expMsg:   "all instances are available",
+		},
+		{
+			name:     "instance initializing",
+			pl:       newStatePlacement(shard.Available, shard.Available, shard.Initializing),
+			sets:     func() []*appsv1.StatefulSet { return readySets(t, cluster) },
+			expState: myspec.YellowState,
+			expMsg:   "1/3 instances unavailable; 2 shards have fewer than 3 available replicas",
+		},
+		{
+			name:     "quorum lost",
+			pl:       newStatePlacement(shard.Available, shard.Initializing, shard.Initializing),
+			sets:     func() []*appsv1.StatefulSet { return readySets(t, cluster) },
+			expState: myspec.RedState,
+			expMsg:   "2/3 instances unavailable; 2 shards have fewer than 2 available replicas",
+		},
+		{
+			name: "set not ready",
+			pl:   newStatePlacement(shard.Available, shard.Available, shard.Available),
+			sets: func() []*appsv1.StatefulSet {
+				sets := readySets(t, cluster)
+				sets[0].Status.ReadyReplicas = 2
+				return sets
+			},
+			expState: myspec.YellowState,
+			expMsg:   "statefulset cluster-zones-us-fake1-a has 2/3 ready pods",
+		},
+		{
+			name: "missing set",
+			pl:   newStatePlacement(shard.Available, shard.Available, shard.Available),
+			sets: func() []*appsv1.StatefulSet {
+				return readySets(t, cluster)[:2]
+			},
+			expState: myspec.YellowState,
+			expMsg:   "2/3 statefulsets created",
+		},
+		{
+			name:     "no placement",
+			sets:     func() []*appsv1.StatefulSet { return readySets(t, cluster) },
+			expState: myspec.YellowState,
+			expMsg:   "placement unavailable",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			state, msg := clusterState(cluster, test.pl, test.sets())
+			assert.Equal(t, test.expState, state)
+			assert.Equal(t, test.expMsg, msg)
+		})
+	}
+}
+
+func TestUpdateClusterState(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Generation = 3
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	pl := newStatePlacement(shard.Available, shard.Available, shard.Available)
+	cluster, err := controller.updateClusterState(cluster, pl, readySets(t, cluster))
+	require.NoError(t, err)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, myspec.GreenState, cluster.Status.State)
+	assert.Equal(t, "all instances are available", cluster.Status.Message)
+	assert.Equal(t, int64(3), cluster.Status.ObservedGeneration)
+	assert.Equal(t, int32(9), cluster.Status.Instances)
+	assert.Equal(t, "operator.m3db.io/app=m3db,operator.m3db.io/cluster=cluster-zones,operator.m3db.io/component=m3dbnode",
+		cluster.Status.LabelSelector)
+
+	pl = newStatePlacement(shard.Available, shard.Initializing, shard.Initializing)
+	cluster, err = controller.updateClusterState(cluster, pl, readySets(t, cluster))
+	require.NoError(t, err)
+	assert.Equal(t, myspec.RedState, cluster.Status.State)
+}
+
+func TestTrackBootstrappingInstances(t *testing.T) {
+	now := time.Date(2018, 11, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour).Format(time.RFC3339)
+
+	prev := []myspec.BootstrappingInstance{
+		{ID: "1", Since: earlier},
+		{ID: "2", Since: earlier},
+		{ID: "removed", Since: earlier},
+	}
+	pl := newStatePlacement(shard.Available, shard.Available, shard.Initializing, shard.Initializing)
+
+	bootstrapping, finished := trackBootstrappingInstances(prev, pl, now)
+	assert.Equal(t, []myspec.BootstrappingInstance{
+		{ID: "2", Since: earlier},
+		{ID: "3", Since: now.Format(time.RFC3339)},
+	}, bootstrapping)
+	assert.Equal(t, map[string]time.Duration{"1": time.Hour}, finished)
+}
+
+func TestUpdateClusterStateBootstrapTimeouts(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Spec.BootstrapTimeouts = &myspec.BootstrapTimeouts{
+		DegradedAfterSeconds: pointer.Int64Ptr(600),
+		RedAfterSeconds:      pointer.Int64Ptr(3600),
+	}
+
+	fakeClock := clock.NewFakeClock(time.Date(2018, 11, 1, 12, 0, 0, 0, time.UTC))
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+		clock:      fakeClock,
+	})
+	controller := deps.newController()
+	scope := tally.NewTestScope("", nil)
+	controller.scope = scope
+	defer deps.cleanup()
+
+	pl := newStatePlacement(shard.Available, shard.Available, shard.Initializing)
+	cluster, err := controller.updateClusterState(cluster, pl, readySets(t, cluster))
+	require.NoError(t, err)
+	assert.Equal(t, myspec.YellowState, cluster.Status.State)
+	require.Len(t, cluster.Status.BootstrappingInstances, 1)
+	assert.Equal(t, "2", cluster.Status.BootstrappingInstances[0].ID)
+	_, ok := cluster.Status.GetCondition(myspec.ClusterConditionDegraded)
+	assert.False(t, ok)
+
+	// Past the degraded timeout the cluster is degraded but not yet red.
+	fakeClock.Step(11 * time.Minute)
+	cluster, err = controller.updateClusterState(cluster, pl, readySets(t, cluster))
+	require.NoError(t, err)
+	assert.Equal(t, myspec.YellowState, cluster.Status.State)
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionDegraded)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "BootstrapTimeout", cond.Reason)
+
+	// Past the red timeout the cluster is red.
+	fakeClock.Step(time.Hour)
+	cluster, err = controller.updateClusterState(cluster, pl, readySets(t, cluster))
+	require.NoError(t, err)
+	assert.Equal(t, myspec.RedState, cluster.Status.State)
+	assert.Contains(t, cluster.Status.Message, "1 instances bootstrapping for longer than 1h0m0s")
+
+	// Once the instance is available the cluster recovers.
+	pl = newStatePlacement(shard.Available, shard.Available, shard.Available)
+	cluster, err = controller.updateClusterState(cluster, pl, readySets(t, cluster))
+	require.NoError(t, err)
+	assert.Equal(t, myspec.GreenState, cluster.Status.State)
+	assert.Empty(t, cluster.Status.BootstrappingInstances)
+	cond, ok = cluster.Status.GetCondition(myspec.ClusterConditionDegraded)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+
+	timers := scope.Snapshot().Timers()
+	timer, ok := timers["instance_bootstrap_duration+cluster=cluster-zones,instance=2"]
+	require.True(t, ok)
+	assert.Equal(t, []time.Duration{71 * time.Minute}, timer.Values())
+}
+
+func TestNextBootstrapTimeout(t *testing.T) {
+	now := time.Date(2018, 11, 1, 12, 0, 0, 0, time.UTC)
+	insts := []myspec.BootstrappingInstance{
+		{ID: "1", Since: now.Add(-5 * time.Minute).Format(time.RFC3339)},
+		{ID: "2", Since: now.Add(-8 * time.Minute).Format(time.RFC3339)},
+		{ID: "3", Since: "invalid"},
+	}
+
+	// Instance 2 is the first to exceed the degraded timeout.
+	after, ok := nextBootstrapTimeout(insts, now, 10*time.Minute, time.Hour)
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Minute+time.Second, after)
+
+	// Once both are degraded, the next timeout is when instance 2 goes red.
+	after, ok = nextBootstrapTimeout(insts, now.Add(15*time.Minute), 10*time.Minute, time.Hour)
+	require.True(t, ok)
+	assert.Equal(t, 37*time.Minute+time.Second, after)
+
+	_, ok = nextBootstrapTimeout(insts, now.Add(2*time.Hour), 10*time.Minute, time.Hour)
+	assert.False(t, ok)
+
+	_, ok = nextBootstrapTimeout(nil, now, 10*time.Minute, time.Hour)
+	assert.False(t, ok)
+}
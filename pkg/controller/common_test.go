@@ -62,7 +62,11 @@ type testDeps struct {
 	idProvider        *podidentity.MockProvider
 	statefulSetLister appsv1listers.StatefulSetLister
 	podLister         corev1listers.PodLister
+	configMapLister   corev1listers.ConfigMapLister
+	serviceLister     corev1listers.ServiceLister
+	nodeLister        corev1listers.NodeLister
 	crdLister         crdlisters.M3DBClusterLister
+	namespaceLister   crdlisters.M3DBNamespaceLister
 	placementClient   *placement.MockClient
 	namespaceClient   *namespace.MockClient
 	clock             clock.Clock
@@ -89,11 +93,16 @@ func (deps *testDeps) newController() *Controller {
 		crdClient:     deps.crdClient,
 		podIDProvider: deps.idProvider,
 
-		clusterWorkQueue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), clusterWorkQueueName),
-		podWorkQueue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), podWorkQueueName),
-		clusterLister:     deps.crdLister,
-		statefulSetLister: deps.statefulSetLister,
-		podLister:         deps.podLister,
+		clusterWorkQueue:   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), clusterWorkQueueName),
+		podWorkQueue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), podWorkQueueName),
+		namespaceWorkQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), namespaceWorkQueueName),
+		clusterLister:      deps.crdLister,
+		namespaceLister:    deps.namespaceLister,
+		statefulSetLister:  deps.statefulSetLister,
+		podLister:          deps.podLister,
+		configMapLister:    deps.configMapLister,
+		serviceLister:      deps.serviceLister,
+		nodeLister:         deps.nodeLister,
 
 		recorder: eventer.NewNopPoster(),
 	}
@@ -126,13 +135,21 @@ func newTestDeps(t *testing.T, opts *testOpts) *testDeps {
 	kubeInformers := kubeinformers.NewSharedInformerFactory(deps.kubeClient, 0)
 	sets := kubeInformers.Apps().V1().StatefulSets()
 	pods := kubeInformers.Core().V1().Pods()
+	configMaps := kubeInformers.Core().V1().ConfigMaps()
+	services := kubeInformers.Core().V1().Services()
+	nodes := kubeInformers.Core().V1().Nodes()
 
 	crdInformers := crdinformers.NewSharedInformerFactory(deps.crdClient, 0)
 	crds := crdInformers.Operator().V1alpha1().M3DBClusters()
+	namespaces := crdInformers.Operator().V1alpha1().M3DBNamespaces()
 
 	deps.statefulSetLister = sets.Lister()
 	deps.podLister = pods.Lister()
+	deps.configMapLister = configMaps.Lister()
+	deps.serviceLister = services.Lister()
+	deps.nodeLister = nodes.Lister()
 	deps.crdLister = crds.Lister()
+	deps.namespaceLister = namespaces.Lister()
 
 	go kubeInformers.Start(deps.stopCh)
 	go crdInformers.Start(deps.stopCh)
@@ -142,7 +159,11 @@ func newTestDeps(t *testing.T, opts *testOpts) *testDeps {
 		warmCh <- cache.WaitForCacheSync(deps.stopCh,
 			sets.Informer().HasSynced,
 			pods.Informer().HasSynced,
+			configMaps.Informer().HasSynced,
+			services.Informer().HasSynced,
+			nodes.Informer().HasSynced,
 			crds.Informer().HasSynced,
+			namespaces.Informer().HasSynced,
 		)
 	}()
 
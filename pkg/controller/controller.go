@@ -23,8 +23,11 @@ package controller
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
@@ -92,14 +95,29 @@ type Controller struct {
 
 	clusterLister      clusterlisters.M3DBClusterLister
 	clustersSynced     cache.InformerSynced
+	namespaceLister    clusterlisters.M3DBNamespaceLister
+	namespacesSynced   cache.InformerSynced
 	statefulSetLister  appslisters.StatefulSetLister
 	statefulSetsSynced cache.InformerSynced
 	podLister          corelisters.PodLister
 	podsSynced         cache.InformerSynced
-
-	clusterWorkQueue workqueue.RateLimitingInterface
-	podWorkQueue     workqueue.RateLimitingInterface
-	recorder         eventer.Poster
+	configMapLister    corelisters.ConfigMapLister
+	configMapsSynced   cache.InformerSynced
+	serviceLister      corelisters.ServiceLister
+	servicesSynced     cache.InformerSynced
+	nodeLister         corelisters.NodeLister
+	nodesSynced        cache.InformerSynced
+
+	podReplacementGracePeriod  time.Duration
+	podReplacementDeleteClaims bool
+	stuckPods                  stuckPodTracker
+	podRestarts                podRestartGate
+	dryRun                     bool
+
+	clusterWorkQueue   workqueue.RateLimitingInterface
+	podWorkQueue       workqueue.RateLimitingInterface
+	namespaceWorkQueue workqueue.RateLimitingInterface
+	recorder           eventer.Poster
 }
 
 // New creates new instance of Controller
@@ -137,12 +155,17 @@ func New(opts ...Option) (*Controller, error) {
 
 	statefulSetInformer := kubeInformerFactory.Apps().V1().StatefulSets()
 	podInformer := kubeInformerFactory.Core().V1().Pods()
+	configMapInformer := kubeInformerFactory.Core().V1().ConfigMaps()
+	serviceInformer := kubeInformerFactory.Core().V1().Services()
+	nodeInformer := kubeInformerFactory.Core().V1().Nodes()
 	m3dbClusterInformer := m3dbClusterInformerFactory.Operator().V1alpha1().M3DBClusters()
+	m3dbNamespaceInformer := m3dbClusterInformerFactory.Operator().V1alpha1().M3DBNamespaces()
 
 	samplescheme.AddToScheme(scheme.Scheme)
 
 	clusterWorkQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), clusterWorkQueueName)
 	podWorkQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), podWorkQueueName)
+	namespaceWorkQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), namespaceWorkQueueName)
 
 	r, err := eventer.NewEventRecorder(eventer.WithClient(kubeClient), eventer.WithLogger(logger), eventer.WithComponent(controllerName))
 	if err != nil {
@@ -164,30 +187,74 @@ func New(opts ...Option) (*Controller, error) {
 
 		clusterLister:      m3dbClusterInformer.Lister(),
 		clustersSynced:     m3dbClusterInformer.Informer().HasSynced,
+		namespaceLister:    m3dbNamespaceInformer.Lister(),
+		namespacesSynced:   m3dbNamespaceInformer.Informer().HasSynced,
 		statefulSetLister:  statefulSetInformer.Lister(),
 		statefulSetsSynced: statefulSetInformer.Informer().HasSynced,
 		podLister:          podInformer.Lister(),
 		podsSynced:         podInformer.Informer().HasSynced,
-
-		clusterWorkQueue: clusterWorkQueue,
-		podWorkQueue:     podWorkQueue,
+		configMapLister:    configMapInformer.Lister(),
+		configMapsSynced:   configMapInformer.Informer().HasSynced,
+		serviceLister:      serviceInformer.Lister(),
+		servicesSynced:     serviceInformer.Informer().HasSynced,
+		nodeLister:         nodeInformer.Lister(),
+		nodesSynced:        nodeInformer.Informer().HasSynced,
+
+		podReplacementGracePeriod:  options.podReplacementGracePeriod,
+		podReplacementDeleteClaims: options.podReplacementDeleteClaims,
+		dryRun:                     options.dryRun,
+
+		clusterWorkQueue:   clusterWorkQueue,
+		podWorkQueue:       podWorkQueue,
+		namespaceWorkQueue: namespaceWorkQueue,
 		// TODO(celina): figure out if we actually need a recorder for each namespace
 		recorder: r,
 	}
 
 	m3dbClusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: p.enqueueCluster,
+		AddFunc: func(obj interface{}) {
+			p.enqueueCluster(obj)
+			p.enqueueClusterNamespaces(obj.(*myspec.M3DBCluster))
+		},
 		UpdateFunc: func(old, new interface{}) {
 			p.enqueueCluster(new)
+
+			// The namespaces of a cluster depend on its spec, annotations and
+			// whether its placement is initialized.
+			oldCluster := old.(*myspec.M3DBCluster)
+			newCluster := new.(*myspec.M3DBCluster)
+			if !reflect.DeepEqual(oldCluster.Spec, newCluster.Spec) ||
+				!reflect.DeepEqual(oldCluster.Annotations, newCluster.Annotations) ||
+				oldCluster.Status.HasInitializedPlacement() != newCluster.Status.HasInitializedPlacement() {
+				p.enqueueClusterNamespaces(newCluster)
+			}
 		},
 		DeleteFunc: func(obj interface{}) {
-			// TODO(schallert): what do we want to do on delete? clean up the etcd
-			// data? we don't need to do anything w/ the sts + pods, we set owner refs
-			// so kubernetes will GC them for us
+			// etcd data is cleaned up before the cluster's finalizer is removed, and
+			// we set owner refs on the sts + pods so kubernetes will GC them for us.
 			logger.Info("deleted cluster")
+			if cluster, ok := obj.(*myspec.M3DBCluster); ok {
+				p.enqueueClusterNamespaces(cluster)
+			}
 		},
 	})
 
+	m3dbNamespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: p.enqueueNamespace,
+		UpdateFunc: func(old, new interface{}) {
+			p.enqueueNamespace(new)
+
+			// If the namespace was moved to another cluster or renamed the old
+			// cluster may have to prune it.
+			oldNs := old.(*myspec.M3DBNamespace)
+			newNs := new.(*myspec.M3DBNamespace)
+			if oldNs.Spec.ClusterName != newNs.Spec.ClusterName || oldNs.NamespaceName() != newNs.NamespaceName() {
+				p.handleNamespaceDelete(oldNs)
+			}
+		},
+		DeleteFunc: p.handleNamespaceDelete,
+	})
+
 	statefulSetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: p.handleStatefulSetUpdate,
 		UpdateFunc: func(old, new interface{}) {
@@ -209,9 +276,39 @@ func New(opts ...Option) (*Controller, error) {
 		AddFunc: p.enqueuePod,
 		UpdateFunc: func(old, new interface{}) {
 			p.enqueuePod(new)
+
+			oldPod := old.(*corev1.Pod)
+			newPod := new.(*corev1.Pod)
+			if oldPod.Status.Phase != newPod.Status.Phase || isPodReady(oldPod) != isPodReady(newPod) {
+				p.enqueuePodCluster(newPod)
+			}
 		},
-		DeleteFunc: func(obj interface{}) {
-			// No-op
+		DeleteFunc: p.handlePodDelete,
+	})
+
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			oldNode := old.(*corev1.Node)
+			newNode := new.(*corev1.Node)
+			if isNodeReady(oldNode) != isNodeReady(newNode) {
+				p.handleNodeChange(newNode)
+			}
+		},
+		DeleteFunc: p.handleNodeChange,
+	})
+
+	configMapInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: p.handleConfigMapUpdate,
+		UpdateFunc: func(old, new interface{}) {
+			oldCM := old.(*corev1.ConfigMap)
+			newCM := new.(*corev1.ConfigMap)
+
+			if newCM.ResourceVersion == oldCM.ResourceVersion {
+				// don't have to reprocess on periodic resync
+				return
+			}
+
+			p.handleConfigMapUpdate(new)
 		},
 	})
 
@@ -220,18 +317,22 @@ func New(opts ...Option) (*Controller, error) {
 
 // Init ensures all the required resources are created
 func (c *Controller) Init() error {
-	return c.k8sclient.CreateCRD(m3dboperator.Name)
+	if err := c.k8sclient.CreateCRD(m3dboperator.Name); err != nil {
+		return err
+	}
+	return c.k8sclient.CreateCRD(m3dboperator.NamespaceName)
 }
 
 // Run drives the controller event loop.
 func (c *Controller) Run(nWorkers int, stopCh <-chan struct{}) error {
 	defer runtime.HandleCrash()
 	defer c.clusterWorkQueue.ShutDown()
+	defer c.namespaceWorkQueue.ShutDown()
 
 	c.logger.Info("starting Operator controller")
 
 	c.logger.Info("waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.clustersSynced, c.statefulSetsSynced, c.podsSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.clustersSynced, c.namespacesSynced, c.statefulSetsSynced, c.podsSynced, c.configMapsSynced, c.servicesSynced, c.nodesSynced); !ok {
 		return errors.New("caches failed to sync")
 	}
 
@@ -239,6 +340,7 @@ func (c *Controller) Run(nWorkers int, stopCh <-chan struct{}) error {
 	for i := 0; i < nWorkers; i++ {
 		go c.runClusterLoop()
 		go c.runPodLoop()
+		go c.runNamespaceLoop()
 	}
 
 	c.logger.Info("workers started")
@@ -335,6 +437,37 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 
 	clusterLogger := c.logger.With(zap.String("cluster", cluster.Name))
 
+	if cluster.DeletionTimestamp != nil {
+		return c.handleClusterDeletion(cluster)
+	}
+
+	cluster, err := c.setPausedStatus(cluster)
+	if err != nil {
+		clusterLogger.Error("failed to update paused status", zap.Error(err))
+		return err
+	}
+
+	if cluster.Spec.Paused {
+		clusterLogger.Info("cluster is paused, not making any changes")
+		return c.refreshPausedCluster(cluster)
+	}
+
+	if c.isDryRun(cluster) {
+		clusterLogger.Info("dry run enabled, planning changes to cluster")
+		return c.planCluster(cluster)
+	}
+
+	cluster, err = c.setPlannedActions(cluster, nil)
+	if err != nil {
+		return err
+	}
+
+	cluster, _, err = c.ensureFinalizer(cluster)
+	if err != nil {
+		clusterLogger.Error("failed to ensure finalizer", zap.Error(err))
+		return err
+	}
+
 	if err := c.ensureConfigMap(cluster); err != nil {
 		clusterLogger.Error("failed to ensure configmap", zap.Error(err))
 		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, "failed to ensure configmap: %s", err.Error())
@@ -352,9 +485,19 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 		return nil
 	}
 
-	// copy since we sort the array
-	isoGroups := make([]myspec.IsolationGroup, len(cluster.Spec.IsolationGroups))
-	copy(isoGroups, cluster.Spec.IsolationGroups)
+	// Sets left behind by a previous incarnation of the cluster must be adopted
+	// rather than recreated. Wait for the adoption to be reflected in the cache.
+	adopted, err := c.adoptOrphanedStatefulSets(cluster)
+	if err != nil {
+		clusterLogger.Error("failed to adopt orphaned statefulsets", zap.Error(err))
+		return err
+	}
+	if adopted {
+		return nil
+	}
+
+	// DesiredIsolationGroups returns a copy, which we sort.
+	isoGroups := cluster.Spec.DesiredIsolationGroups()
 	sort.Sort(myspec.IsolationGroups(isoGroups))
 
 	childrenSets, err := c.getChildStatefulSets(cluster)
@@ -362,44 +505,67 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 		return err
 	}
 
+	// Pods force deleted off failed nodes are replaced in the placement as soon
+	// as they're recreated, as they may not become ready until they are.
+	replaced, err := c.replaceForcedPods(cluster, childrenSets)
+	if err != nil {
+		return err
+	}
+	if replaced {
+		return nil
+	}
+
 	for _, sts := range childrenSets {
 		// if any of the statefulsets aren't ready, wait until they are as we'll get
 		// another event (ready == bootstrapped)
 		if sts.Spec.Replicas != nil && *sts.Spec.Replicas != sts.Status.ReadyReplicas {
 			// TODO(schallert): figure out what to do if replicas is not set
 			c.logger.Info("waiting for statefulset to be ready", zap.String("name", sts.Name), zap.Int32("ready", sts.Status.ReadyReplicas))
-			return nil
+
+			// If a pod is stuck on a dead node the set will never become ready on
+			// its own.
+			if _, err := c.replaceStuckPod(cluster, sts); err != nil {
+				return err
+			}
+
+			_, err := c.updateClusterState(cluster, c.activePlacement(cluster), childrenSets)
+			return err
 		}
 	}
 
 	// At this point all existing statefulsets are bootstrapped.
 
-	if len(childrenSets) != len(isoGroups) {
-		nextID := len(childrenSets)
-		// create a statefulset
+	// Create a set for the first isolation group that doesn't have one. Sets of
+	// removed groups are decommissioned first.
+	if len(removedGroupSets(isoGroups, childrenSets)) == 0 {
+		for _, group := range isoGroups {
+			if setForIsolationGroup(childrenSets, group.Name) != nil {
+				continue
+			}
 
-		name := fmt.Sprintf("%s-%d", cluster.Name, nextID)
-		sts, err := k8sops.GenerateStatefulSet(cluster, isoGroups[nextID].Name, isoGroups[nextID].NumInstances)
-		if err != nil {
-			return err
-		}
+			sts, err := c.generateStatefulSet(cluster, group.Name, group.NumInstances)
+			if err != nil {
+				return err
+			}
 
-		_, err = c.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Create(sts)
-		if err != nil {
-			c.logger.Error(err.Error())
-			return err
-		}
+			_, err = c.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Create(sts)
+			if err != nil {
+				c.logger.Error(err.Error())
+				return err
+			}
 
-		c.logger.Info("created statefulset", zap.String("name", name))
-		return nil
+			c.logger.Info("created statefulset", zap.String("name", sts.Name))
+			return nil
+		}
 	}
 
-	if err := c.reconcileNamespaces(cluster); err != nil {
+	cluster, err = c.reconcileNamespaces(cluster)
+	if err != nil {
 		c.logger.Error("error reconciling namespaces", zap.Error(err))
 		return err
 	}
 
-	if len(cluster.Spec.Namespaces) == 0 {
+	if desired, err := c.desiredNamespaces(cluster); err == nil && len(desired) == 0 {
 		c.logger.Warn("cluster has no namespaces defined", zap.String("cluster", cluster.Name))
 		c.recorder.WarningEvent(cluster, eventer.ReasonUnknown, "cluster %s has no namespaces", cluster.Name)
 	}
@@ -427,6 +593,11 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 
 	c.logger.Info("found placement", zap.Int("currentPods", len(pods)), zap.Int("placementInsts", placement.NumInstances()))
 
+	cluster, err = c.updateClusterState(cluster, placement, childrenSets)
+	if err != nil {
+		return err
+	}
+
 	unavailInsts := []string{}
 	for _, inst := range placement.Instances() {
 		if !inst.IsAvailable() {
@@ -448,7 +619,21 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 		return err
 	}
 
-	// check if any pods inside the cluster need to be swapped in
+	// If any isolation groups were removed from the spec, drain their instances
+	// from the placement and delete their sets before making other changes.
+	decommissioning, err := c.decommissionRemovedGroups(cluster, placement, childrenSets)
+	if err != nil {
+		return err
+	}
+	if decommissioning {
+		return nil
+	}
+
+	// Check if any pods inside the cluster need to be swapped in. This must
+	// happen before any pods are restarted: a pod restarted with a new identity
+	// (e.g. a new UID) leaves its old instance marked available in the
+	// placement, and restarting another pod before it's replaced would take
+	// more than one replica of its shards offline.
 	leavingInstanceID, podToReplace, err := c.checkPodsForReplacement(cluster, pods, placement)
 	if err != nil {
 		return err
@@ -461,6 +646,41 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 			return err
 		}
 		c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulUpdate, "successfully replaced instance: "+leavingInstanceID)
+
+		// Wait for the replacement to finish streaming before making any other
+		// changes.
+		return nil
+	}
+
+	// If the pod template of any set has drifted from the cluster spec (e.g. the
+	// image was changed) update the set, then restart its pods one at a time.
+	// Only one pod is restarted per pass and we never get here while any
+	// instance is unavailable or waiting to be replaced, so at most one replica
+	// of a shard is offline.
+	updated, err := c.updateStatefulSetTemplates(cluster, childrenSets)
+	if err != nil {
+		return err
+	}
+	if updated {
+		return nil
+	}
+
+	restarted, err := c.restartOutdatedPods(cluster, childrenSets, pods, placement)
+	if err != nil {
+		return err
+	}
+	if restarted {
+		return nil
+	}
+
+	if cluster.Spec.ScalingPolicy.BatchExpansion {
+		expanded, err := c.expandPlacementBatch(cluster, childrenSets, isoGroups, placement)
+		if err != nil {
+			return err
+		}
+		if expanded {
+			return nil
+		}
 	}
 
 	for _, set := range childrenSets {
@@ -489,34 +709,24 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 			zap.Int32("desired", desired),
 		)
 
-		if desired == current {
-			// If the set is at its desired size, and all pods in the set are in the
-			// placement, there's nothing we need to do for this set.
-			if current == inPlacement {
-				continue
-			}
-
-			// If the set is at its desired size but there's pods in the set that are
-			// absent from the placement, add pods to placement.
-			if inPlacement < current {
-				setLogger.Info("expanding placement for set")
-				return c.expandPlacementForSet(cluster, set, group, placement)
-			}
-		}
-
-		// If there are more pods in the placement than we want in the group,
-		// trigger a remove so that we can shrink the set.
-		if inPlacement > desired {
-			setLogger.Info("remove instance from placement for set")
-			return c.shrinkPlacementForSet(cluster, set)
+		step, newCount := nextScaleStep(cluster, desired, current, inPlacement)
+		switch step {
+		case scaleStepNone:
+			continue
+		case scaleStepAddInstance:
+			setLogger.Info("expanding placement for set")
+			return c.expandPlacementForSet(cluster, set, group, placement)
+		case scaleStepRemoveBlocked:
+			msg := shrinkBlockedReason(cluster, group.Name)
+			setLogger.Warn(msg)
+			c.recorder.WarningEvent(cluster, eventer.ReasonFailedToUpdate, msg)
+			return nil
+		case scaleStepRemoveInstance:
+			count := instancesToRemove(cluster, desired, inPlacement)
+			setLogger.Info("remove instances from placement for set", zap.Int32("count", count))
+			return c.shrinkPlacementForSet(cluster, set, placement, desired, int(count))
 		}
 
-		var newCount int32
-		if current < desired {
-			newCount = current + 1
-		} else {
-			newCount = current - 1
-		}
 		setLogger.Info("resizing set, desired != current", zap.Int32("newSize", newCount))
 
 		set.Spec.Replicas = pointer.Int32Ptr(newCount)
@@ -601,8 +811,19 @@ func (c *Controller) handleStatefulSetUpdate(obj interface{}) {
 	c.logger.Info("processing statefulset", zap.String("name", object.GetName()))
 
 	owner := metav1.GetControllerOf(object)
-	// TODO(schallert): const
-	if owner == nil || owner.Kind != "m3dbcluster" {
+	if owner == nil {
+		// An orphaned set may belong to a cluster that should adopt it.
+		if clusterName, ok := object.GetLabels()[labels.Cluster]; ok {
+			if cluster, err := c.clusterLister.M3DBClusters(object.GetNamespace()).Get(clusterName); err == nil {
+				c.enqueueCluster(cluster)
+			}
+		}
+		return
+	}
+
+	// Sets created by earlier versions of the operator refer to their cluster
+	// with a lowercase kind.
+	if !strings.EqualFold(owner.Kind, m3dboperator.ResourceKind) {
 		return
 	}
 
@@ -616,6 +837,97 @@ func (c *Controller) handleStatefulSetUpdate(obj interface{}) {
 	c.enqueueCluster(cluster)
 }
 
+// handlePodDelete enqueues the parent cluster of a deleted pod so that the pod
+// can be replaced promptly.
+func (c *Controller) handlePodDelete(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+			return
+		}
+
+		pod, ok = tombstone.Obj.(*corev1.Pod)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding tombstone, invalid type"))
+			return
+		}
+	}
+
+	c.logger.Info("pod deleted", zap.String("pod", pod.Name))
+	c.enqueuePodCluster(pod)
+}
+
+// handleNodeChange enqueues the clusters of all pods on a node that was
+// deleted or changed readiness.
+func (c *Controller) handleNodeChange(obj interface{}) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+			return
+		}
+
+		node, ok = tombstone.Obj.(*corev1.Node)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding tombstone, invalid type"))
+			return
+		}
+	}
+
+	pods, err := c.podLister.List(klabels.Everything())
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("error listing pods: %v", err))
+		return
+	}
+
+	for _, pod := range pods {
+		if pod.Spec.NodeName == node.Name {
+			c.enqueuePodCluster(pod)
+		}
+	}
+}
+
+// enqueuePodCluster enqueues the parent cluster of a pod, if it has one.
+func (c *Controller) enqueuePodCluster(pod *corev1.Pod) {
+	cluster, err := c.getParentCluster(pod)
+	if err != nil {
+		return
+	}
+
+	c.enqueueCluster(cluster)
+}
+
+// handleConfigMapUpdate enqueues every cluster whose pods mount the given
+// ConfigMap, so that changes to the m3 configuration are rolled out.
+func (c *Controller) handleConfigMapUpdate(obj interface{}) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+		return
+	}
+
+	clusters, err := c.clusterLister.M3DBClusters(cm.Namespace).List(klabels.Everything())
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("error listing clusters: %v", err))
+		return
+	}
+
+	for _, cluster := range clusters {
+		name, err := k8sops.ConfigMapName(cluster)
+		if err != nil || name != cm.Name {
+			continue
+		}
+
+		c.logger.Info("configmap changed, processing cluster",
+			zap.String("configMap", cm.Name),
+			zap.String("cluster", cluster.Name))
+		c.enqueueCluster(cluster)
+	}
+}
+
 func (c *Controller) enqueuePod(obj interface{}) {
 	var key string
 	var err error
@@ -725,15 +1037,19 @@ func (c *Controller) handlePodUpdate(pod *corev1.Pod) error {
 
 	currentID, ok := pod.Annotations[podidentity.AnnotationKeyPodIdentity]
 	if ok {
-		if currentID != idStr {
-			podLogger.Warn("pod ID mismatch",
-				zap.String("currentID", currentID),
-				zap.String("newID", idStr))
+		if currentID == idStr {
+			return nil
 		}
 
-		// TODO(schallert): decide how to enforce updated pod identity (need to
-		// determine ramnifications of changing). Will likely need to do a replace.
-		return nil
+		podLogger.Warn("pod ID mismatch",
+			zap.String("currentID", currentID),
+			zap.String("newID", idStr))
+
+		if podIdentityChangePolicy(cluster) != myspec.PodIdentityChangePolicyReplace {
+			return nil
+		}
+
+		return c.enforcePodIdentity(cluster, pod, currentID, idStr)
 	}
 
 	if pod.Annotations == nil {
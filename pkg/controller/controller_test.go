@@ -25,6 +25,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 	clientsetfake "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/fake"
 	m3dbinformers "github.com/m3db/m3db-operator/pkg/client/informers/externalversions"
@@ -41,8 +42,10 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/golang/mock/gomock"
+	"github.com/kubernetes/utils/pointer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/uber-go/tally"
@@ -136,7 +139,7 @@ func TestGetChildStatefulSets(t *testing.T) {
 				*metav1.NewControllerRef(cluster, schema.GroupVersionKind{
 					Group:   myspec.SchemeGroupVersion.Group,
 					Version: myspec.SchemeGroupVersion.Version,
-					Kind:    "m3dbcluster",
+					Kind:    m3dboperator.ResourceKind,
 				}),
 			})
 			_, err := deps.kubeClient.AppsV1().StatefulSets("namespace").Update(set)
@@ -283,6 +286,160 @@ func TestHandlePodUpdate(t *testing.T) {
 	assert.Equal(t, expID, annotatedID)
 }
 
+func TestHandleConfigMapUpdate(t *testing.T) {
+	cluster := &myspec.M3DBCluster{
+		ObjectMeta: newObjectMeta("foo", nil),
+	}
+
+	otherCluster := &myspec.M3DBCluster{
+		ObjectMeta: newObjectMeta("bar", nil),
+		Spec: myspec.ClusterSpec{
+			ConfigMapName: pointer.StringPtr("custom-config"),
+		},
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, otherCluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController()
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: newObjectMeta("unrelated", nil),
+	}
+	c.handleConfigMapUpdate(cm)
+
+	cm = &corev1.ConfigMap{
+		ObjectMeta: newObjectMeta("custom-config", nil),
+	}
+	c.handleConfigMapUpdate(cm)
+
+	err := wait.Poll(time.Millisecond, 5*time.Second, func() (bool, error) {
+		return c.clusterWorkQueue.Len() == 1, nil
+	})
+	require.NoError(t, err)
+
+	key, _ := c.clusterWorkQueue.Get()
+	assert.Equal(t, "namespace/bar", key)
+}
+
+func TestHandleStatefulSetUpdate(t *testing.T) {
+	var objects []runtime.Object
+	for _, name := range []string{"a", "b", "c"} {
+		objects = append(objects, &myspec.M3DBCluster{
+			ObjectMeta: newObjectMeta(name, nil),
+		})
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: objects,
+	})
+	defer deps.cleanup()
+	c := deps.newController()
+
+	setOwnedBy := func(kind, clusterName string) *appsv1.StatefulSet {
+		set := &appsv1.StatefulSet{
+			ObjectMeta: newObjectMeta("set-"+clusterName, nil),
+		}
+		set.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: myspec.SchemeGroupVersion.String(),
+				Kind:       kind,
+				Name:       clusterName,
+				Controller: pointer.BoolPtr(true),
+			},
+		}
+		return set
+	}
+
+	// Sets created by earlier versions of the operator use a lowercase kind.
+	c.handleStatefulSetUpdate(setOwnedBy(m3dboperator.ResourceKind, "a"))
+	c.handleStatefulSetUpdate(setOwnedBy("m3dbcluster", "b"))
+	c.handleStatefulSetUpdate(setOwnedBy("Deployment", "c"))
+
+	err := wait.Poll(time.Millisecond, 5*time.Second, func() (bool, error) {
+		return c.clusterWorkQueue.Len() == 2, nil
+	})
+	require.NoError(t, err)
+
+	var keys []string
+	for c.clusterWorkQueue.Len() > 0 {
+		key, _ := c.clusterWorkQueue.Get()
+		keys = append(keys, key.(string))
+		c.clusterWorkQueue.Done(key)
+	}
+	assert.Equal(t, []string{"namespace/a", "namespace/b"}, keys)
+}
+
+func TestHandlePodDelete(t *testing.T) {
+	cluster := &myspec.M3DBCluster{
+		ObjectMeta: newObjectMeta("foo", nil),
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "namespace",
+			Labels: map[string]string{
+				"operator.m3db.io/cluster": "foo",
+			},
+		},
+	}
+
+	c.handlePodDelete(cache.DeletedFinalStateUnknown{Key: "namespace/pod1", Obj: pod})
+
+	err := wait.Poll(time.Millisecond, 5*time.Second, func() (bool, error) {
+		return c.clusterWorkQueue.Len() == 1, nil
+	})
+	require.NoError(t, err)
+
+	key, _ := c.clusterWorkQueue.Get()
+	assert.Equal(t, "namespace/foo", key)
+}
+
+func TestHandleNodeChange(t *testing.T) {
+	cluster := &myspec.M3DBCluster{
+		ObjectMeta: newObjectMeta("foo", nil),
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "namespace",
+			Labels: map[string]string{
+				"operator.m3db.io/cluster": "foo",
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-a",
+		},
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects:  []runtime.Object{cluster},
+		kubeObjects: []runtime.Object{pod},
+	})
+	defer deps.cleanup()
+	c := deps.newController()
+
+	c.handleNodeChange(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}})
+	c.handleNodeChange(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}})
+
+	err := wait.Poll(time.Millisecond, 5*time.Second, func() (bool, error) {
+		return c.clusterWorkQueue.Len() == 1, nil
+	})
+	require.NoError(t, err)
+
+	key, _ := c.clusterWorkQueue.Get()
+	assert.Equal(t, "namespace/foo", key)
+}
+
 func TestClusterEventLoop(t *testing.T) {
 	deps := newTestDeps(t, &testOpts{})
 	defer deps.cleanup()
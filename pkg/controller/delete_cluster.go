@@ -0,0 +1,186 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// etcdDeletionFinalizer is added to clusters whose placement and namespaces
+	// should be removed from etcd when the cluster is deleted.
+	etcdDeletionFinalizer = "operator.m3db.io/etcd-deletion"
+
+	// etcdCleanupTimeout is how long after a cluster is deleted the operator
+	// keeps trying to remove its data from etcd before giving up. The
+	// coordinator may be unreachable for good, e.g. if the cluster's pods were
+	// deleted first by foreground deletion.
+	etcdCleanupTimeout = 10 * time.Minute
+)
+
+// ensureFinalizer adds the etcd deletion finalizer to a cluster unless it opts
+// out of etcd cleanup, and removes it from one that does. Returns the updated
+// cluster and whether an update was made.
+func (c *Controller) ensureFinalizer(cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, bool, error) {
+	hasFinalizer := stringInSlice(etcdDeletionFinalizer, cluster.Finalizers)
+	wantFinalizer := !cluster.Spec.RetainEtcdDataOnDelete
+
+	if hasFinalizer == wantFinalizer {
+		return cluster, false, nil
+	}
+
+	if wantFinalizer {
+		cluster.Finalizers = append(cluster.Finalizers, etcdDeletionFinalizer)
+	} else {
+		cluster.Finalizers = removeString(etcdDeletionFinalizer, cluster.Finalizers)
+	}
+
+	cluster, err := c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster)
+	if err != nil {
+		return nil, false, fmt.Errorf("error updating cluster finalizers: %v", err)
+	}
+
+	return cluster, true, nil
+}
+
+// handleClusterDeletion is called for clusters that are being deleted. Unless
+// the cluster opts out it deletes all namespaces and the placement from etcd, then
+// removes the finalizer so Kubernetes can finish deleting the cluster. The data
+// is left in etcd if the cluster is deleted with orphan propagation, since its
+// pods are kept and may be adopted by a new cluster of the same name, or if
+// it can't be deleted within etcdCleanupTimeout.
+func (c *Controller) handleClusterDeletion(cluster *myspec.M3DBCluster) error {
+	if !stringInSlice(etcdDeletionFinalizer, cluster.Finalizers) {
+		// Nothing for us to do, kubernetes will garbage collect the cluster's
+		// resources.
+		return nil
+	}
+
+	switch {
+	case cluster.Spec.RetainEtcdDataOnDelete:
+	case stringInSlice(metav1.FinalizerOrphanDependents, cluster.Finalizers):
+		c.logger.Info("cluster deleted with orphan propagation, keeping etcd data", zap.String("cluster", cluster.Name))
+		c.recorder.NormalEvent(cluster, eventer.ReasonDeleting,
+			"cluster deleted with orphan propagation, not deleting placement and namespaces")
+	default:
+		if err := c.deleteEtcdData(cluster); err != nil {
+			deleted := cluster.DeletionTimestamp
+			if deleted == nil || c.clock.Since(deleted.Time) < etcdCleanupTimeout {
+				return err
+			}
+
+			c.logger.Error("giving up deleting etcd data", zap.String("cluster", cluster.Name), zap.Error(err))
+			c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete,
+				"gave up deleting placement and namespaces after %s, they must be deleted manually: %v",
+				etcdCleanupTimeout, err)
+		}
+	}
+
+	cluster.Finalizers = removeString(etcdDeletionFinalizer, cluster.Finalizers)
+	if _, err := c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster); err != nil {
+		return fmt.Errorf("error removing cluster finalizer: %v", err)
+	}
+
+	c.logger.Info("finalized cluster deletion", zap.String("cluster", cluster.Name))
+	return nil
+}
+
+// deleteEtcdData deletes the cluster's namespaces and placement.
+func (c *Controller) deleteEtcdData(cluster *myspec.M3DBCluster) error {
+	if err := c.deleteAllNamespaces(cluster); err != nil {
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete, "failed to delete namespaces: %v", err)
+		return err
+	}
+
+	if err := c.deletePlacement(cluster); err != nil {
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete, "failed to delete placement: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// deleteAllNamespaces deletes every namespace registered in the cluster.
+func (c *Controller) deleteAllNamespaces(cluster *myspec.M3DBCluster) error {
+	nsClient := c.adminClient.namespaceClientForCluster(cluster)
+	resp, err := nsClient.List()
+	if err == m3admin.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error listing namespaces: %v", err)
+	}
+
+	for ns := range resp.Registry.Namespaces {
+		err := nsClient.Delete(ns)
+		if err != nil && err != m3admin.ErrNotFound {
+			return fmt.Errorf("error deleting namespace '%s': %v", ns, err)
+		}
+
+		c.logger.Info("deleted namespace", zap.String("namespace", ns))
+		c.recorder.NormalEvent(cluster, eventer.ReasonDeleting, "deleted namespace "+ns)
+	}
+
+	return nil
+}
+
+// deletePlacement deletes the cluster's placement.
+func (c *Controller) deletePlacement(cluster *myspec.M3DBCluster) error {
+	err := c.adminClient.placementClientForCluster(cluster).Delete()
+	if err == m3admin.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting placement: %v", err)
+	}
+
+	c.logger.Info("deleted placement", zap.String("cluster", cluster.Name))
+	c.recorder.NormalEvent(cluster, eventer.ReasonDeleting, "deleted placement")
+	return nil
+}
+
+func stringInSlice(s string, ss []string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(s string, ss []string) []string {
+	var out []string
+	for _, v := range ss {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
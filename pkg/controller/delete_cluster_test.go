@@ -0,0 +1,179 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+
+	dbns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureFinalizer(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	// Clusters get the finalizer unless they opt out.
+	cluster, updated, err := controller.ensureFinalizer(cluster)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, []string{"operator.m3db.io/etcd-deletion"}, cluster.Finalizers)
+
+	cluster, updated, err = controller.ensureFinalizer(cluster)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	cluster.Spec.RetainEtcdDataOnDelete = true
+	cluster, updated, err = controller.ensureFinalizer(cluster)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Empty(t, cluster.Finalizers)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, cluster.Finalizers)
+}
+
+func TestHandleClusterDeletion(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Finalizers = []string{"foo", "operator.m3db.io/etcd-deletion"}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	resp := &admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{
+			Namespaces: map[string]*dbns.NamespaceOptions{
+				"a": &dbns.NamespaceOptions{},
+				"b": &dbns.NamespaceOptions{},
+			},
+		},
+	}
+	deps.namespaceClient.EXPECT().List().Return(resp, nil)
+	deps.namespaceClient.EXPECT().Delete("a").Return(nil)
+	deps.namespaceClient.EXPECT().Delete("b").Return(m3admin.ErrNotFound)
+	deps.placementClient.EXPECT().Delete().Return(nil)
+
+	err := controller.handleClusterDeletion(cluster.DeepCopy())
+	require.NoError(t, err)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, cluster.Finalizers)
+}
+
+func TestHandleClusterDeletionError(t *testing.T) {
+	now := time.Now()
+	fakeClock := clock.NewFakeClock(now)
+
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Finalizers = []string{"operator.m3db.io/etcd-deletion"}
+	cluster.DeletionTimestamp = &metav1.Time{Time: now}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+		clock:      fakeClock,
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	resp := &admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{},
+	}
+	deps.namespaceClient.EXPECT().List().Return(resp, nil).Times(2)
+	deps.placementClient.EXPECT().Delete().Return(errors.New("placement error")).Times(2)
+
+	err := controller.handleClusterDeletion(cluster.DeepCopy())
+	assert.Error(t, err)
+
+	current, err := deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"operator.m3db.io/etcd-deletion"}, current.Finalizers)
+
+	// Once the cleanup has been failing for long enough the operator gives up
+	// rather than blocking the deletion forever.
+	fakeClock.Step(etcdCleanupTimeout)
+	err = controller.handleClusterDeletion(cluster.DeepCopy())
+	require.NoError(t, err)
+
+	current, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, current.Finalizers)
+}
+
+func TestHandleClusterDeletionKeepData(t *testing.T) {
+	tests := []struct {
+		name       string
+		retain     bool
+		finalizers []string
+	}{
+		{
+			name:       "opted out",
+			retain:     true,
+			finalizers: []string{"operator.m3db.io/etcd-deletion"},
+		},
+		{
+			name:       "orphan propagation",
+			finalizers: []string{metav1.FinalizerOrphanDependents, "operator.m3db.io/etcd-deletion"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := getFixture("cluster-simple.yaml", t)
+			cluster.Finalizers = test.finalizers
+			cluster.Spec.RetainEtcdDataOnDelete = test.retain
+
+			deps := newTestDeps(t, &testOpts{
+				crdObjects: []runtime.Object{cluster},
+			})
+			controller := deps.newController()
+			defer deps.cleanup()
+
+			// No calls expected to the admin clients.
+			err := controller.handleClusterDeletion(cluster.DeepCopy())
+			require.NoError(t, err)
+
+			cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.NotContains(t, cluster.Finalizers, "operator.m3db.io/etcd-deletion")
+		})
+	}
+}
@@ -178,6 +178,10 @@ func (c errorNamespaceClient) Delete(namespace string) error {
 	return c.err
 }
 
+func (c errorNamespaceClient) Update(request *admin.NamespaceAddRequest) error {
+	return c.err
+}
+
 // errorPlacementClient follows the same pattern of errorNamespaceClient for
 // placement.Client.
 type errorPlacementClient struct {
@@ -204,10 +208,18 @@ func (c errorPlacementClient) Add(placementpb.Instance) error {
 	return c.err
 }
 
+func (c errorPlacementClient) AddMany([]placementpb.Instance) error {
+	return c.err
+}
+
 func (c errorPlacementClient) Remove(string) error {
 	return c.err
 }
 
+func (c errorPlacementClient) RemoveMany([]string) error {
+	return c.err
+}
+
 func (c errorPlacementClient) Replace(string, placementpb.Instance) error {
 	return c.err
 }
@@ -0,0 +1,390 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"go.uber.org/zap"
+)
+
+const namespaceWorkQueueName = "m3dbnamespace-work-queue"
+
+func (c *Controller) enqueueNamespace(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.namespaceWorkQueue.AddRateLimited(key)
+	c.scope.Counter("enqueued_event").Inc(int64(1))
+}
+
+// enqueueClusterNamespaces enqueues every M3DBNamespace that references a
+// cluster.
+func (c *Controller) enqueueClusterNamespaces(cluster *myspec.M3DBCluster) {
+	namespaces, err := c.clusterNamespaceResources(cluster.Namespace, cluster.Name)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("error listing namespaces: %v", err))
+		return
+	}
+
+	for _, ns := range namespaces {
+		c.enqueueNamespace(ns)
+	}
+}
+
+// enqueueNamespaceCluster enqueues the cluster an M3DBNamespace references, if
+// it exists.
+func (c *Controller) enqueueNamespaceCluster(ns *myspec.M3DBNamespace) {
+	cluster, err := c.clusterLister.M3DBClusters(ns.Namespace).Get(ns.Spec.ClusterName)
+	if err != nil {
+		return
+	}
+
+	c.enqueueCluster(cluster)
+}
+
+// handleNamespaceDelete requeues the cluster of a deleted M3DBNamespace, which
+// then handles the namespace the same way as one removed from its spec, along
+// with any other M3DBNamespaces of the cluster that may have conflicted with
+// it.
+func (c *Controller) handleNamespaceDelete(obj interface{}) {
+	ns, ok := obj.(*myspec.M3DBNamespace)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+			return
+		}
+
+		ns, ok = tombstone.Obj.(*myspec.M3DBNamespace)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding tombstone, invalid type"))
+			return
+		}
+	}
+
+	c.logger.Info("namespace resource deleted", zap.String("m3dbnamespace", ns.Name))
+	c.enqueueNamespaceCluster(ns)
+
+	others, err := c.clusterNamespaceResources(ns.Namespace, ns.Spec.ClusterName)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("error listing namespaces: %v", err))
+		return
+	}
+	for _, other := range others {
+		if other.NamespaceName() == ns.NamespaceName() {
+			c.enqueueNamespace(other)
+		}
+	}
+}
+
+func (c *Controller) runNamespaceLoop() {
+	for c.processNamespaceQueueItem() {
+	}
+}
+
+func (c *Controller) processNamespaceQueueItem() bool {
+	obj, shutdown := c.namespaceWorkQueue.Get()
+	c.scope.Counter("dequeued_event").Inc(int64(1))
+	if shutdown {
+		return false
+	}
+
+	// Closure so we can defer workQueue.Done.
+	err := func(obj interface{}) error {
+		defer c.namespaceWorkQueue.Done(obj)
+
+		key, ok := obj.(string)
+		if !ok {
+			c.namespaceWorkQueue.Forget(obj)
+			runtime.HandleError(fmt.Errorf("expected string from queue, got %#v", obj))
+			return nil
+		}
+
+		if err := c.handleNamespaceEvent(key); err != nil {
+			return fmt.Errorf("error syncing namespace '%s': %v", key, err)
+		}
+
+		c.namespaceWorkQueue.Forget(obj)
+		c.logger.Info("successfully synced item", zap.String("key", key))
+
+		return nil
+	}(obj)
+
+	if err != nil {
+		runtime.HandleError(err)
+	}
+
+	return true
+}
+
+func (c *Controller) handleNamespaceEvent(key string) error {
+	k8sNamespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	ns, err := c.namespaceLister.M3DBNamespaces(k8sNamespace).Get(name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			// Deleted namespaces are handled by their cluster.
+			return nil
+		}
+
+		return err
+	}
+
+	if ns == nil {
+		return errors.New("got nil namespace for " + key)
+	}
+
+	return c.handleNamespaceUpdate(ns)
+}
+
+// handleNamespaceUpdate creates the namespace an M3DBNamespace describes in its
+// cluster, or updates its options if they differ from the spec, and reports the
+// outcome in the M3DBNamespace's Ready condition. Namespaces are only managed
+// once the cluster's placement is initialized, and are left alone while the
+// cluster is paused or in dry run mode.
+func (c *Controller) handleNamespaceUpdate(ns *myspec.M3DBNamespace) error {
+	ns = ns.DeepCopy()
+	if ns.DeletionTimestamp != nil {
+		return nil
+	}
+
+	name := ns.NamespaceName()
+	nsLogger := c.logger.With(zap.String("m3dbnamespace", ns.Name), zap.String("namespace", name))
+
+	cluster, err := c.clusterLister.M3DBClusters(ns.Namespace).Get(ns.Spec.ClusterName)
+	if kerrors.IsNotFound(err) {
+		return c.setNamespaceReady(ns, corev1.ConditionFalse, "ClusterNotFound",
+			fmt.Sprintf("cluster %s does not exist", ns.Spec.ClusterName))
+	}
+	if err != nil {
+		return err
+	}
+
+	if cluster.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if cluster.Spec.Paused || c.isDryRun(cluster) {
+		nsLogger.Info("cluster is paused or in dry run mode, not making any changes")
+		return nil
+	}
+
+	if !cluster.Status.HasInitializedPlacement() {
+		return c.setNamespaceReady(ns, corev1.ConditionFalse, "ClusterNotReady",
+			fmt.Sprintf("waiting for the placement of cluster %s to be initialized", cluster.Name))
+	}
+
+	conflict, err := c.namespaceConflict(cluster, ns)
+	if err != nil {
+		return err
+	}
+	if conflict != "" {
+		nsLogger.Warn(conflict)
+		c.recorder.WarningEvent(ns, eventer.ReasonFailSync, conflict)
+		return c.setNamespaceReady(ns, corev1.ConditionFalse, "Conflict", conflict)
+	}
+
+	req, err := namespace.RequestFromSpec(ns.DesiredNamespace())
+	if err != nil {
+		// Retrying won't help until the spec is changed.
+		msg := fmt.Sprintf("invalid namespace spec: %v", err)
+		c.recorder.WarningEvent(ns, eventer.ReasonFailSync, msg)
+		return c.setNamespaceReady(ns, corev1.ConditionFalse, "InvalidSpec", msg)
+	}
+
+	nsClient := c.adminClient.namespaceClientForCluster(cluster)
+	resp, err := nsClient.List()
+	if err != nil {
+		nsLogger.Error("failed to get namespaces", zap.Error(err))
+		return err
+	}
+
+	current, ok := resp.Registry.Namespaces[name]
+	if !ok {
+		if err := nsClient.Create(req); err != nil {
+			nsLogger.Error("error creating namespace", zap.Error(err))
+			c.recorder.WarningEvent(ns, eventer.ReasonFailedCreate, "failed to create namespace %s: %v", name, err)
+			return fmt.Errorf("error creating namespace '%s': %v", name, err)
+		}
+
+		nsLogger.Info("created namespace")
+		c.recorder.NormalEvent(ns, eventer.ReasonSuccessfulCreate, "created namespace %s in cluster %s", name, cluster.Name)
+		return c.setNamespaceReady(ns, corev1.ConditionTrue, "Created",
+			fmt.Sprintf("created namespace in cluster %s", cluster.Name))
+	}
+
+	changed, immutable := namespace.OptionsDiff(current, req.Options)
+	if len(immutable) > 0 {
+		msg := fmt.Sprintf("cannot update namespace %s: %s cannot be changed", name, strings.Join(immutable, ", "))
+		nsLogger.Warn(msg)
+		// Only warn when the blocked update changes, not on every resync.
+		if cond, ok := ns.Status.GetCondition(myspec.NamespaceConditionReady); !ok || cond.Message != msg {
+			c.recorder.WarningEvent(ns, eventer.ReasonFailedToUpdate, msg)
+		}
+		return c.setNamespaceReady(ns, corev1.ConditionFalse, "ImmutableOptionsChanged", msg)
+	}
+
+	if len(changed) == 0 {
+		return c.setNamespaceReady(ns, corev1.ConditionTrue, "NamespaceReady", "namespace matches the spec")
+	}
+
+	if err := nsClient.Update(req); err != nil {
+		nsLogger.Error("error updating namespace", zap.Error(err))
+		c.recorder.WarningEvent(ns, eventer.ReasonFailedToUpdate, "failed to update namespace %s: %v", name, err)
+		return fmt.Errorf("error updating namespace '%s': %v", name, err)
+	}
+
+	nsLogger.Info("updated namespace", zap.Strings("fields", changed))
+	c.recorder.NormalEvent(ns, eventer.ReasonSuccessfulUpdate, "updated namespace %s: %s", name,
+		strings.Join(changed, ", "))
+	return c.setNamespaceReady(ns, corev1.ConditionTrue, "NamespaceReady", "namespace matches the spec")
+}
+
+// namespaceConflict returns why an M3DBNamespace can't manage its namespace, if
+// it can't: either the namespace is in the cluster's spec, which takes
+// precedence, or an older M3DBNamespace manages it.
+func (c *Controller) namespaceConflict(cluster *myspec.M3DBCluster, ns *myspec.M3DBNamespace) (string, error) {
+	name := ns.NamespaceName()
+	for _, specNs := range cluster.Spec.Namespaces {
+		if specNs.Name == name {
+			return fmt.Sprintf("namespace %s is managed by the spec of cluster %s", name, cluster.Name), nil
+		}
+	}
+
+	others, err := c.clusterNamespaceResources(cluster.Namespace, cluster.Name)
+	if err != nil {
+		return "", err
+	}
+
+	for _, other := range others {
+		if other.Name == ns.Name || other.NamespaceName() != name || other.DeletionTimestamp != nil {
+			continue
+		}
+		if namespaceResourceOlder(other, ns) {
+			return fmt.Sprintf("namespace %s is managed by M3DBNamespace %s", name, other.Name), nil
+		}
+	}
+
+	return "", nil
+}
+
+// namespaceResourceOlder returns whether a was created before b, using their
+// names to break ties.
+func namespaceResourceOlder(a, b *myspec.M3DBNamespace) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// clusterNamespaceResources returns the M3DBNamespaces in a Kubernetes
+// namespace that reference the given cluster.
+func (c *Controller) clusterNamespaceResources(k8sNamespace, clusterName string) ([]*myspec.M3DBNamespace, error) {
+	all, err := c.namespaceLister.M3DBNamespaces(k8sNamespace).List(klabels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaces []*myspec.M3DBNamespace
+	for _, ns := range all {
+		if ns.Spec.ClusterName == clusterName {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces, nil
+}
+
+// desiredNamespaces returns the namespaces in a cluster's spec along with those
+// of the M3DBNamespaces that reference it. None of them are pruned from the
+// cluster.
+func (c *Controller) desiredNamespaces(cluster *myspec.M3DBCluster) ([]myspec.Namespace, error) {
+	resources, err := c.clusterNamespaceResources(cluster.Namespace, cluster.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error listing namespaces: %v", err)
+	}
+
+	namespaces := make([]myspec.Namespace, 0, len(cluster.Spec.Namespaces)+len(resources))
+	namespaces = append(namespaces, cluster.Spec.Namespaces...)
+	for _, ns := range resources {
+		namespaces = append(namespaces, ns.DesiredNamespace())
+	}
+	return namespaces, nil
+}
+
+// setNamespaceReady sets the Ready condition of an M3DBNamespace along with the
+// generation it was observed at. The status is only written if it changes.
+func (c *Controller) setNamespaceReady(ns *myspec.M3DBNamespace, status corev1.ConditionStatus, reason, message string) error {
+	cond, ok := ns.Status.GetCondition(myspec.NamespaceConditionReady)
+	if ok && cond.Status == status && cond.Reason == reason && cond.Message == message &&
+		ns.Status.ObservedGeneration == ns.Generation {
+		return nil
+	}
+
+	client := c.crdClient.OperatorV1alpha1().M3DBNamespaces(ns.Namespace)
+	current := ns.DeepCopy()
+	return retryStatusUpdate(func() error {
+		cond, ok := current.Status.GetCondition(myspec.NamespaceConditionReady)
+		if !ok {
+			cond = myspec.NamespaceCondition{
+				Type:   myspec.NamespaceConditionReady,
+				Status: corev1.ConditionUnknown,
+			}
+		}
+
+		curTime := c.clock.Now().UTC().Format(time.RFC3339)
+		if cond.Status != status {
+			cond.LastTransitionTime = curTime
+		}
+
+		cond.Status = status
+		cond.LastUpdateTime = curTime
+		cond.Reason = reason
+		cond.Message = message
+		current.Status.UpdateCondition(cond)
+		current.Status.ObservedGeneration = ns.Generation
+
+		_, err := client.UpdateStatus(current)
+		return err
+	}, func() (err error) {
+		current, err = client.Get(ns.Name, metav1.GetOptions{})
+		return err
+	})
+}
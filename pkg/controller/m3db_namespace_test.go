@@ -0,0 +1,266 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package controller
+
+import (
+	"testing"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+
+	dbns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubernetes/utils/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNamespaceResource(name, clusterName string) *myspec.M3DBNamespace {
+	return &myspec.M3DBNamespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "fake",
+			Generation:        1,
+			CreationTimestamp: metav1.NewTime(time.Unix(1000, 0)),
+		},
+		Spec: myspec.NamespaceSpec{
+			ClusterName: clusterName,
+			Preset:      "10s:2d",
+		},
+	}
+}
+
+func initializedCluster(t *testing.T) *myspec.M3DBCluster {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Status.UpdateCondition(myspec.ClusterCondition{
+		Type:   myspec.ClusterConditionPlacementInitialized,
+		Status: corev1.ConditionTrue,
+	})
+	return cluster
+}
+
+func namespaceReadyCondition(t *testing.T, deps *testDeps, name string) myspec.NamespaceCondition {
+	ns, err := deps.crdClient.OperatorV1alpha1().M3DBNamespaces("fake").Get(name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, ns.Generation, ns.Status.ObservedGeneration)
+
+	cond, ok := ns.Status.GetCondition(myspec.NamespaceConditionReady)
+	require.True(t, ok)
+	return cond
+}
+
+func TestHandleNamespaceUpdateCreate(t *testing.T) {
+	cluster := initializedCluster(t)
+	ns := newNamespaceResource("team-a", cluster.Name)
+	ns.Spec.Name = "team-a-10s:2d"
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, ns},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	deps.namespaceClient.EXPECT().List().Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{}},
+	}, nil)
+	deps.namespaceClient.EXPECT().Create(namespaceMatcher{"team-a-10s:2d"}).Return(nil)
+
+	require.NoError(t, controller.handleNamespaceUpdate(ns))
+
+	cond := namespaceReadyCondition(t, deps, ns.Name)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Created", cond.Reason)
+}
+
+func TestHandleNamespaceUpdateOptions(t *testing.T) {
+	cluster := initializedCluster(t)
+	ns := newNamespaceResource("team-a", cluster.Name)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, ns},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	req, err := namespace.RequestFromSpec(ns.DesiredNamespace())
+	require.NoError(t, err)
+
+	registry := &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+		"team-a": req.Options,
+	}}
+	deps.namespaceClient.EXPECT().List().Return(&admin.NamespaceGetResponse{Registry: registry}, nil).AnyTimes()
+
+	getNs := func() *myspec.M3DBNamespace {
+		ns, err := deps.crdClient.OperatorV1alpha1().M3DBNamespaces("fake").Get("team-a", metav1.GetOptions{})
+		require.NoError(t, err)
+		return ns
+	}
+
+	// No changes.
+	require.NoError(t, controller.handleNamespaceUpdate(ns))
+	cond := namespaceReadyCondition(t, deps, ns.Name)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "NamespaceReady", cond.Reason)
+
+	// Retention period changes are applied.
+	current := *req.Options
+	retention := *req.Options.RetentionOptions
+	retention.RetentionPeriodNanos = (7 * 24 * time.Hour).Nanoseconds()
+	current.RetentionOptions = &retention
+	registry.Namespaces["team-a"] = &current
+
+	deps.namespaceClient.EXPECT().Update(namespaceMatcher{"team-a"}).Return(nil)
+	require.NoError(t, controller.handleNamespaceUpdate(getNs()))
+
+	// Immutable changes block the update.
+	retention.BlockSizeNanos = (4 * time.Hour).Nanoseconds()
+
+	require.NoError(t, controller.handleNamespaceUpdate(getNs()))
+	cond = namespaceReadyCondition(t, deps, ns.Name)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, "ImmutableOptionsChanged", cond.Reason)
+	assert.Equal(t, "cannot update namespace team-a: retentionOptions.blockSize cannot be changed", cond.Message)
+}
+
+func TestHandleNamespaceUpdateWaits(t *testing.T) {
+	initialized := initializedCluster(t)
+
+	paused := initializedCluster(t)
+	paused.Name = "cluster-paused"
+	paused.Spec.Paused = true
+
+	dryRun := initializedCluster(t)
+	dryRun.Name = "cluster-dry-run"
+	dryRun.Annotations = map[string]string{annotations.DryRun: "true"}
+
+	uninitialized := getFixture("cluster-simple.yaml", t)
+	uninitialized.Name = "cluster-uninitialized"
+
+	tests := []struct {
+		ns     *myspec.M3DBNamespace
+		reason string
+	}{
+		{ns: newNamespaceResource("missing", "cluster-missing"), reason: "ClusterNotFound"},
+		{ns: newNamespaceResource("uninitialized", uninitialized.Name), reason: "ClusterNotReady"},
+		{ns: newNamespaceResource("paused", paused.Name)},
+		{ns: newNamespaceResource("dry-run", dryRun.Name)},
+		{ns: newNamespaceResource("invalid", initialized.Name), reason: "InvalidSpec"},
+	}
+	tests[4].ns.Spec.Preset = "foo"
+
+	objects := []runtime.Object{initialized, paused, dryRun, uninitialized}
+	for _, test := range tests {
+		objects = append(objects, test.ns)
+	}
+
+	deps := newTestDeps(t, &testOpts{crdObjects: objects})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	// No namespaces are created or updated.
+	for _, test := range tests {
+		require.NoError(t, controller.handleNamespaceUpdate(test.ns))
+
+		ns, err := deps.crdClient.OperatorV1alpha1().M3DBNamespaces("fake").Get(test.ns.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		cond, ok := ns.Status.GetCondition(myspec.NamespaceConditionReady)
+		if test.reason == "" {
+			assert.False(t, ok, test.ns.Name)
+			continue
+		}
+
+		require.True(t, ok, test.ns.Name)
+		assert.Equal(t, corev1.ConditionFalse, cond.Status, test.ns.Name)
+		assert.Equal(t, test.reason, cond.Reason, test.ns.Name)
+	}
+}
+
+func TestHandleNamespaceUpdateConflict(t *testing.T) {
+	cluster := initializedCluster(t)
+
+	// Conflicts with the cluster's spec.
+	inSpec := newNamespaceResource("in-spec", cluster.Name)
+	inSpec.Spec.Name = "metrics-10s:2d"
+
+	// The older of two resources with the same namespace wins.
+	older := newNamespaceResource("older", cluster.Name)
+	older.Spec.Name = "shared"
+	newer := newNamespaceResource("newer", cluster.Name)
+	newer.Spec.Name = "shared"
+	newer.CreationTimestamp = metav1.NewTime(time.Unix(2000, 0))
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, inSpec, older, newer},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	require.NoError(t, controller.handleNamespaceUpdate(inSpec))
+	cond := namespaceReadyCondition(t, deps, inSpec.Name)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, "Conflict", cond.Reason)
+	assert.Equal(t, "namespace metrics-10s:2d is managed by the spec of cluster cluster-simple", cond.Message)
+
+	require.NoError(t, controller.handleNamespaceUpdate(newer))
+	cond = namespaceReadyCondition(t, deps, newer.Name)
+	assert.Equal(t, "Conflict", cond.Reason)
+	assert.Equal(t, "namespace shared is managed by M3DBNamespace older", cond.Message)
+
+	deps.namespaceClient.EXPECT().List().Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{}},
+	}, nil)
+	deps.namespaceClient.EXPECT().Create(namespaceMatcher{"shared"}).Return(nil)
+	require.NoError(t, controller.handleNamespaceUpdate(older))
+	cond = namespaceReadyCondition(t, deps, older.Name)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+}
+
+func TestPruneNamespacesKeepsNamespaceResources(t *testing.T) {
+	cluster := initializedCluster(t)
+	cluster.Spec.Namespaces = nil
+	cluster.Spec.NamespaceDeletionPolicy = myspec.NamespaceDeletionPolicyDelete
+	cluster.Spec.NamespaceDeletionGracePeriodSeconds = pointer.Int64Ptr(0)
+
+	ns := newNamespaceResource("team-a", cluster.Name)
+	other := newNamespaceResource("team-b", "other-cluster")
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, ns, other},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	registry := &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+		"team-a": {},
+		"team-b": {},
+	}}
+
+	// Only namespaces of M3DBNamespaces referencing the cluster are kept.
+	deps.namespaceClient.EXPECT().Delete("team-b").Return(nil)
+	_, err := controller.pruneNamespaces(cluster, registry)
+	require.NoError(t, err)
+}
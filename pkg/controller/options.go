@@ -22,6 +22,7 @@ package controller
 
 import (
 	"errors"
+	"time"
 
 	clientset "github.com/m3db/m3db-operator/pkg/client/clientset/versioned"
 	informers "github.com/m3db/m3db-operator/pkg/client/informers/externalversions"
@@ -50,6 +51,9 @@ type options struct {
 	kubeInformerFactory        kubeinformers.SharedInformerFactory
 	m3dbClusterInformerFactory informers.SharedInformerFactory
 	kubectlProxy               bool
+	podReplacementGracePeriod  time.Duration
+	podReplacementDeleteClaims bool
+	dryRun                     bool
 }
 
 type optionFn func(o *options)
@@ -115,6 +119,34 @@ func WithKubectlProxy(use bool) Option {
 	})
 }
 
+// WithPodReplacementGracePeriod sets how long a pod may be stuck on a node that
+// is gone or not ready before the controller deletes it so that it's recreated
+// on a healthy node and replaced in the placement. A zero duration disables
+// this.
+func WithPodReplacementGracePeriod(d time.Duration) Option {
+	return optionFn(func(o *options) {
+		o.podReplacementGracePeriod = d
+	})
+}
+
+// WithPodReplacementDeleteClaims sets whether the controller also deletes the
+// volume claims of a stuck pod it deletes, if the recreated pod will be
+// replaced in the placement. This permanently deletes the pod's data, but is
+// required for pods whose volumes are bound to the failed node.
+func WithPodReplacementDeleteClaims(deleteClaims bool) Option {
+	return optionFn(func(o *options) {
+		o.podReplacementDeleteClaims = deleteClaims
+	})
+}
+
+// WithDryRun sets whether the controller only plans the changes it would make
+// to clusters, recording them in each cluster's status, rather than making them.
+func WithDryRun(dryRun bool) Option {
+	return optionFn(func(o *options) {
+		o.dryRun = dryRun
+	})
+}
+
 // WithPodIdentityProvider sets the pod identity provider.
 func WithPodIdentityProvider(p podidentity.Provider) Option {
 	return optionFn(func(o *options) {
@@ -22,6 +22,7 @@ package controller
 
 import (
 	"testing"
+	"time"
 
 	clientsetfake "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/fake"
 	m3dbinformers "github.com/m3db/m3db-operator/pkg/client/informers/externalversions"
@@ -54,6 +55,9 @@ func TestOptions(t *testing.T) {
 		WithCRDClient(crdClient),
 		WithKubeClient(kubeClient),
 		WithPodIdentityProvider(provider),
+		WithPodReplacementGracePeriod(time.Minute),
+		WithPodReplacementDeleteClaims(true),
+		WithDryRun(true),
 		WithKubeInformerFactory(kubeinformers.NewSharedInformerFactory(kubeClient, 0)),
 		WithM3DBClusterInformerFactory(m3dbinformers.NewSharedInformerFactory(crdClient, 0)),
 	} {
@@ -62,4 +66,6 @@ func TestOptions(t *testing.T) {
 	}
 
 	assert.NoError(t, opts.validate())
+	assert.Equal(t, time.Minute, opts.podReplacementGracePeriod)
+	assert.True(t, opts.podReplacementDeleteClaims)
 }
@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"go.uber.org/zap"
+)
+
+// setPausedStatus records whether a cluster is paused in its Paused condition.
+// The condition is only written when it changes, and isn't added to clusters
+// that have never been paused.
+func (c *Controller) setPausedStatus(cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, error) {
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionPaused)
+	paused := ok && cond.Status == corev1.ConditionTrue
+	if paused == cluster.Spec.Paused {
+		return cluster, nil
+	}
+
+	if cluster.Spec.Paused {
+		c.logger.Info("pausing cluster", zap.String("cluster", cluster.Name))
+		c.recorder.NormalEvent(cluster, eventer.ReasonUpdating, "cluster paused, no changes will be made")
+		return c.setStatus(cluster, myspec.ClusterConditionPaused, corev1.ConditionTrue,
+			"ClusterPaused", "cluster is paused and under manual control")
+	}
+
+	c.logger.Info("resuming cluster", zap.String("cluster", cluster.Name))
+	c.recorder.NormalEvent(cluster, eventer.ReasonUpdating, "cluster resumed")
+	return c.setStatus(cluster, myspec.ClusterConditionPaused, corev1.ConditionFalse,
+		"ClusterResumed", "cluster is managed by the operator")
+}
+
+// refreshPausedCluster updates the state of a paused cluster without making any
+// changes to it.
+func (c *Controller) refreshPausedCluster(cluster *myspec.M3DBCluster) error {
+	sets, err := c.getChildStatefulSets(cluster)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.updateClusterState(cluster, c.activePlacement(cluster), sets)
+	return err
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPausedStatus(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	// Clusters that were never paused don't get the condition.
+	cluster, err := controller.setPausedStatus(cluster)
+	require.NoError(t, err)
+	_, ok := cluster.Status.GetCondition(myspec.ClusterConditionPaused)
+	assert.False(t, ok)
+
+	cluster.Spec.Paused = true
+	cluster, err = controller.setPausedStatus(cluster)
+	require.NoError(t, err)
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionPaused)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+
+	cluster.Spec.Paused = false
+	cluster, err = controller.setPausedStatus(cluster)
+	require.NoError(t, err)
+	cond, ok = cluster.Status.GetCondition(myspec.ClusterConditionPaused)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+}
+
+func TestHandleClusterUpdatePaused(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Spec.Paused = true
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	// The mocked placement and namespace clients expect no calls, so any
+	// attempt to change the cluster fails the test.
+	err := controller.handleClusterUpdate(cluster)
+	require.NoError(t, err)
+
+	sets, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, sets.Items)
+
+	cms, err := deps.kubeClient.CoreV1().ConfigMaps(cluster.Namespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, cms.Items)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionPaused)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, myspec.YellowState, cluster.Status.State)
+	assert.Empty(t, cluster.Finalizers)
+}
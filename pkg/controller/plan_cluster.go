@@ -0,0 +1,434 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	"github.com/m3db/m3/src/cluster/placement"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"go.uber.org/zap"
+)
+
+// isDryRun returns whether the controller should only plan changes to a
+// cluster, either because the whole operator is in dry run mode or because the
+// cluster is annotated.
+func (c *Controller) isDryRun(cluster *myspec.M3DBCluster) bool {
+	return c.dryRun || cluster.Annotations[annotations.DryRun] == "true"
+}
+
+// planCluster records the actions the controller would take for a cluster in
+// its status, and posts an event when they change, without taking them.
+func (c *Controller) planCluster(cluster *myspec.M3DBCluster) error {
+	sets, err := c.getChildStatefulSets(cluster)
+	if err != nil {
+		return err
+	}
+
+	pl := c.activePlacement(cluster)
+	plan, err := c.planClusterActions(cluster, sets, pl)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(plan, cluster.Status.PlannedActions) {
+		c.logger.Info("planned cluster actions", zap.String("cluster", cluster.Name), zap.Strings("actions", plan))
+		if len(plan) == 0 {
+			c.recorder.NormalEvent(cluster, eventer.ReasonSyncing, "dry run: no changes planned")
+		} else {
+			c.recorder.NormalEvent(cluster, eventer.ReasonSyncing, "dry run: %s", strings.Join(plan, "; "))
+		}
+	}
+
+	cluster, err = c.setPlannedActions(cluster, plan)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.updateClusterState(cluster, pl, sets)
+	return err
+}
+
+// setPlannedActions writes a cluster's planned actions to its status if they
+// changed.
+func (c *Controller) setPlannedActions(cluster *myspec.M3DBCluster, plan []string) (*myspec.M3DBCluster, error) {
+	if len(plan) == 0 && len(cluster.Status.PlannedActions) == 0 {
+		return cluster, nil
+	}
+	if reflect.DeepEqual(plan, cluster.Status.PlannedActions) {
+		return cluster, nil
+	}
+
+	updated, err := c.updateStatus(cluster, func(status *myspec.M3DBStatus) {
+		status.PlannedActions = plan
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating planned actions: %v", err)
+	}
+
+	return updated, nil
+}
+
+// planClusterActions returns the actions handleClusterUpdate would take to
+// bring a cluster in line with its spec, in the order it would take them.
+// Whereas handleClusterUpdate takes one action per sync and waits for the
+// cluster to settle before taking the next, the plan assumes every action
+// succeeds. The plan is built from the same decisions handleClusterUpdate
+// makes, e.g. nextScaleStep for each isolation group. pl may be nil if the
+// cluster has no placement yet.
+func (c *Controller) planClusterActions(
+	cluster *myspec.M3DBCluster,
+	sets []*appsv1.StatefulSet,
+	pl placement.Placement,
+) ([]string, error) {
+	var plan []string
+
+	missing, err := c.missingClusterResources(cluster)
+	if err != nil {
+		return nil, err
+	}
+	plan = append(plan, missing...)
+
+	orphans, err := c.orphanedStatefulSets(cluster)
+	if err != nil {
+		return nil, err
+	}
+	for _, set := range orphans {
+		plan = append(plan, fmt.Sprintf("adopt statefulset %s", set.Name))
+	}
+	// Plan the remaining actions as though the orphans were adopted.
+	sets = append(append([]*appsv1.StatefulSet{}, sets...), orphans...)
+
+	// DesiredIsolationGroups returns a copy, which we sort.
+	isoGroups := cluster.Spec.DesiredIsolationGroups()
+	sort.Sort(myspec.IsolationGroups(isoGroups))
+
+	// Sets for new groups are only created once the sets of removed groups are
+	// gone.
+	removed := removedGroupSets(isoGroups, sets)
+	if len(removed) == 0 {
+		plan = append(plan, planCreateStatefulSets(cluster, isoGroups, sets)...)
+	}
+
+	plan = append(plan, c.planNamespaceActions(cluster)...)
+
+	if pl == nil {
+		plan = append(plan, "initialize placement")
+	}
+
+	if len(removed) > 0 {
+		if msg, blocked := removedGroupsBlockedReason(cluster); blocked {
+			// No other changes are made until the removed groups can be
+			// decommissioned.
+			return append(plan, msg), nil
+		}
+
+		for _, set := range removed {
+			group := set.Labels[labels.IsolationGroup]
+			if pl != nil {
+				if n := len(instancesInIsoGroup(pl, group)); n > 0 {
+					plan = append(plan, fmt.Sprintf("remove %d instance(s) of isolation group %s from placement", n, group))
+				}
+			}
+			plan = append(plan, fmt.Sprintf("delete statefulset %s", set.Name))
+		}
+
+		plan = append(plan, planCreateStatefulSets(cluster, isoGroups, sets)...)
+	}
+
+	if pl != nil {
+		pods, err := c.podLister.Pods(cluster.Namespace).List(klabels.SelectorFromSet(labels.BaseLabels(cluster)))
+		if err != nil {
+			return nil, fmt.Errorf("error listing pods: %v", err)
+		}
+
+		leavingInstanceID, newPod, err := c.checkPodsForReplacement(cluster, pods, pl)
+		if err != nil {
+			return nil, err
+		}
+		if newPod != nil {
+			plan = append(plan, fmt.Sprintf("replace instance %s with pod %s in placement", leavingInstanceID, newPod.Name))
+		}
+	}
+
+	groups := make([]*plannedGroup, 0, len(isoGroups))
+	for _, group := range isoGroups {
+		g := &plannedGroup{
+			name:    group.Name,
+			setName: k8sops.StatefulSetNameForGroup(cluster.Name, group.Name),
+			desired: group.NumInstances,
+			// Sets that don't exist yet are created with the desired number of
+			// pods.
+			current: group.NumInstances,
+		}
+
+		if set := setForIsolationGroup(sets, group.Name); set != nil {
+			_, outdated, err := c.statefulSetTemplateOutdated(cluster, set)
+			if err != nil {
+				return nil, err
+			}
+			if outdated {
+				plan = append(plan, fmt.Sprintf("update pod template of statefulset %s and restart its pods", set.Name))
+			}
+
+			g.setName = set.Name
+			g.current = *set.Spec.Replicas
+		}
+
+		// Initializing the placement adds every pod to it.
+		g.inPlacement = g.current
+		if pl != nil {
+			g.inPlacement = int32(len(instancesInIsoGroup(pl, group.Name)))
+		}
+
+		groups = append(groups, g)
+	}
+
+	if cluster.Spec.ScalingPolicy.BatchExpansion {
+		plan = append(plan, planBatchExpansion(groups)...)
+	}
+
+	for _, g := range groups {
+		steps, blocked := g.planScaleSteps(cluster)
+		plan = append(plan, steps...)
+		if blocked {
+			// handleClusterUpdate stops at the blocked group on every sync.
+			break
+		}
+	}
+
+	return plan, nil
+}
+
+// planCreateStatefulSets returns actions to create the sets of isolation
+// groups that don't have one.
+func planCreateStatefulSets(
+	cluster *myspec.M3DBCluster,
+	isoGroups []myspec.IsolationGroup,
+	sets []*appsv1.StatefulSet,
+) []string {
+	var plan []string
+	for _, group := range isoGroups {
+		if setForIsolationGroup(sets, group.Name) == nil {
+			name := k8sops.StatefulSetNameForGroup(cluster.Name, group.Name)
+			plan = append(plan, fmt.Sprintf("create statefulset %s with %d replica(s)", name, group.NumInstances))
+		}
+	}
+	return plan
+}
+
+// plannedGroup is the state of an isolation group's set and its instances in
+// the placement as the plan progresses.
+type plannedGroup struct {
+	name        string
+	setName     string
+	desired     int32
+	current     int32
+	inPlacement int32
+}
+
+// planBatchExpansion returns the actions expandPlacementBatch would take:
+// growing sets straight to their desired size and then adding all of their
+// new pods to the placement at once.
+func planBatchExpansion(groups []*plannedGroup) []string {
+	var plan []string
+	for _, g := range groups {
+		if g.current < g.desired {
+			plan = append(plan, fmt.Sprintf("scale statefulset %s from %d to %d replica(s)", g.setName, g.current, g.desired))
+			g.current = g.desired
+		}
+	}
+
+	var added int32
+	for _, g := range groups {
+		if g.current == g.desired && g.inPlacement < g.desired {
+			added += g.current - g.inPlacement
+			g.inPlacement = g.current
+		}
+	}
+	if added > 0 {
+		plan = append(plan, fmt.Sprintf("add %d instance(s) to placement in a single operation", added))
+	}
+
+	return plan
+}
+
+// planScaleSteps returns the actions taken to bring a group to its desired
+// size, by stepping it through nextScaleStep until no steps remain. Runs of
+// the same step are combined into a single action. Returns true if the group
+// can't be shrunk, in which case no further steps are taken for any group.
+func (g *plannedGroup) planScaleSteps(cluster *myspec.M3DBCluster) ([]string, bool) {
+	var (
+		plan  []string
+		run   = scaleStepNone
+		count int32
+		from  int32
+	)
+
+	flush := func() {
+		switch run {
+		case scaleStepAddInstance:
+			plan = append(plan, fmt.Sprintf("add %d instance(s) of isolation group %s to placement", count, g.name))
+		case scaleStepRemoveInstance:
+			action := fmt.Sprintf("remove %d instance(s) of isolation group %s from placement", count, g.name)
+			if limit := maxInstancesRemovedPerStep(cluster); limit > 1 {
+				action += fmt.Sprintf(", up to %d per placement change", limit)
+			}
+			plan = append(plan, action)
+		case scaleStepResizeSet:
+			plan = append(plan, fmt.Sprintf("scale statefulset %s from %d to %d replica(s)", g.setName, from, g.current))
+		}
+		run, count = scaleStepNone, 0
+	}
+
+	for {
+		step, newCount := nextScaleStep(cluster, g.desired, g.current, g.inPlacement)
+		if step != run {
+			flush()
+			run, from = step, g.current
+		}
+
+		switch step {
+		case scaleStepNone:
+			return plan, false
+		case scaleStepRemoveBlocked:
+			return append(plan, shrinkBlockedReason(cluster, g.name)), true
+		case scaleStepAddInstance:
+			g.inPlacement++
+			count++
+		case scaleStepRemoveInstance:
+			n := instancesToRemove(cluster, g.desired, g.inPlacement)
+			g.inPlacement -= n
+			count += n
+		case scaleStepResizeSet:
+			g.current = newCount
+		}
+	}
+}
+
+// missingClusterResources returns actions to create the ConfigMap and services
+// the operator creates for a cluster, if they don't exist.
+func (c *Controller) missingClusterResources(cluster *myspec.M3DBCluster) ([]string, error) {
+	var plan []string
+
+	if cluster.Spec.ConfigMapName == nil {
+		cmName, err := k8sops.ConfigMapName(cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = c.configMapLister.ConfigMaps(cluster.Namespace).Get(cmName)
+		if kerrors.IsNotFound(err) {
+			plan = append(plan, "create configmap "+cmName)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	coordSvc, err := k8sops.GenerateCoordinatorService(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	m3dbSvc, err := k8sops.GenerateM3DBService(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, svc := range []*corev1.Service{coordSvc, m3dbSvc} {
+		_, err := c.serviceLister.Services(cluster.Namespace).Get(svc.Name)
+		if kerrors.IsNotFound(err) {
+			plan = append(plan, "create service "+svc.Name)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// planNamespaceActions returns the namespace changes reconcileNamespaces would
+// make. If the cluster's namespaces can't be fetched, e.g. because it's still
+// being created, the plan says so rather than failing.
+func (c *Controller) planNamespaceActions(cluster *myspec.M3DBCluster) []string {
+	resp, err := c.adminClient.namespaceClientForCluster(cluster).List()
+	if err != nil {
+		return []string{fmt.Sprintf("unable to plan namespace changes: %v", err)}
+	}
+
+	var plan []string
+
+	desired, err := c.desiredNamespaces(cluster)
+	if err != nil {
+		return []string{fmt.Sprintf("unable to plan namespace changes: %v", err)}
+	}
+
+	toDelete := namespacesToDelete(resp.Registry, desired)
+	sort.Strings(toDelete)
+	for _, ns := range toDelete {
+		if namespaceDeletionAllowed(cluster, ns) {
+			plan = append(plan, "delete namespace "+ns)
+		}
+	}
+
+	for _, ns := range namespacesToCreate(resp.Registry, desired) {
+		plan = append(plan, "create namespace "+ns.Name)
+	}
+
+	for _, ns := range desired {
+		current, ok := resp.Registry.Namespaces[ns.Name]
+		if !ok {
+			continue
+		}
+
+		req, err := namespace.RequestFromSpec(ns)
+		if err != nil {
+			plan = append(plan, fmt.Sprintf("unable to plan changes to namespace %s: %v", ns.Name, err))
+			continue
+		}
+
+		changed, immutable := namespace.OptionsDiff(current, req.Options)
+		switch {
+		case len(immutable) > 0:
+			plan = append(plan, fmt.Sprintf("cannot update namespace %s: %s cannot be changed", ns.Name,
+				strings.Join(immutable, ", ")))
+		case len(changed) > 0:
+			plan = append(plan, fmt.Sprintf("update namespace %s: %s", ns.Name, strings.Join(changed, ", ")))
+		}
+	}
+
+	return plan
+}
@@ -0,0 +1,308 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+
+	dbns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes/utils/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanClusterActionsNewCluster(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	deps.namespaceClient.EXPECT().List().Return(nil, errors.New("coordinator unavailable"))
+
+	plan, err := controller.planClusterActions(cluster, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"create configmap m3db-config-map-cluster-zones",
+		"create service m3coordinator-cluster-zones",
+		"create service m3dbnode-cluster-zones",
+		"create statefulset cluster-zones-us-fake1-a with 3 replica(s)",
+		"create statefulset cluster-zones-us-fake1-b with 3 replica(s)",
+		"create statefulset cluster-zones-us-fake1-c with 3 replica(s)",
+		"unable to plan namespace changes: coordinator unavailable",
+		"initialize placement",
+	}, plan)
+}
+
+func TestPlanClusterActionsNewClusterReplicas(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Spec.Replicas = pointer.Int32Ptr(4)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	deps.namespaceClient.EXPECT().List().Return(nil, errors.New("coordinator unavailable"))
+
+	plan, err := controller.planClusterActions(cluster, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"create configmap m3db-config-map-cluster-zones",
+		"create service m3coordinator-cluster-zones",
+		"create service m3dbnode-cluster-zones",
+		"create statefulset cluster-zones-us-fake1-a with 2 replica(s)",
+		"create statefulset cluster-zones-us-fake1-b with 1 replica(s)",
+		"create statefulset cluster-zones-us-fake1-c with 1 replica(s)",
+		"unable to plan namespace changes: coordinator unavailable",
+		"initialize placement",
+	}, plan)
+}
+
+func TestPlanClusterActionsScale(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	cm, err := k8sops.GenerateDefaultConfigMap(cluster)
+	require.NoError(t, err)
+	cm.Namespace = cluster.Namespace
+
+	coordSvc, err := k8sops.GenerateCoordinatorService(cluster)
+	require.NoError(t, err)
+	coordSvc.Namespace = cluster.Namespace
+
+	m3dbSvc, err := k8sops.GenerateM3DBService(cluster)
+	require.NoError(t, err)
+	m3dbSvc.Namespace = cluster.Namespace
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{cm, coordSvc, m3dbSvc},
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	var (
+		sets []*appsv1.StatefulSet
+		pods []*corev1.Pod
+	)
+	for _, group := range cluster.Spec.IsolationGroups {
+		set, err := controller.generateStatefulSet(cluster, group.Name, group.NumInstances)
+		require.NoError(t, err)
+		sets = append(sets, set)
+		pods = append(pods, podsForClusterSet(cluster, set, int(group.NumInstances))...)
+	}
+
+	for _, pod := range pods {
+		pod := pod
+		deps.idProvider.EXPECT().Identity(newPodNameMatcher(pod.Name), gomock.Any()).Return(identityForPod(pod), nil).AnyTimes()
+	}
+	pl := placementFromPods(t, cluster, pods, deps.idProvider)
+
+	req, err := namespace.RequestFromSpec(cluster.Spec.Namespaces[0])
+	require.NoError(t, err)
+	deps.namespaceClient.EXPECT().List().Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+			"metrics-10s:2d": req.Options,
+			"retained":       &dbns.NamespaceOptions{},
+		}},
+	}, nil)
+
+	cluster.Spec.IsolationGroups[0].NumInstances = 4
+	cluster.Spec.IsolationGroups[1].NumInstances = 2
+	cluster.Spec.Namespaces = append(cluster.Spec.Namespaces, myspec.Namespace{
+		Name:   "metrics-1m:40d",
+		Preset: "1m:40d",
+	})
+
+	plan, err := controller.planClusterActions(cluster, sets, pl)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"create namespace metrics-1m:40d",
+		"scale statefulset cluster-zones-us-fake1-a from 3 to 4 replica(s)",
+		"add 1 instance(s) of isolation group us-fake1-a to placement",
+		"remove 1 instance(s) of isolation group us-fake1-b from placement",
+		"scale statefulset cluster-zones-us-fake1-b from 3 to 2 replica(s)",
+	}, plan)
+}
+
+func TestPlanClusterActionsShrinkBlocked(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	var sets []*appsv1.StatefulSet
+	for _, group := range cluster.Spec.IsolationGroups {
+		set, err := controller.generateStatefulSet(cluster, group.Name, group.NumInstances)
+		require.NoError(t, err)
+		sets = append(sets, set)
+	}
+
+	deps.namespaceClient.EXPECT().List().Return(nil, errors.New("coordinator unavailable"))
+
+	// Emptying a group with RF 3 would leave too few groups, so the cluster is
+	// stuck at the first group and the later ones are never scaled.
+	cluster.Spec.IsolationGroups[0].NumInstances = 0
+	cluster.Spec.IsolationGroups[1].NumInstances = 4
+
+	plan, err := controller.planClusterActions(cluster, sets, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"create configmap m3db-config-map-cluster-zones",
+		"create service m3coordinator-cluster-zones",
+		"create service m3dbnode-cluster-zones",
+		"unable to plan namespace changes: coordinator unavailable",
+		"initialize placement",
+		"cannot remove all instances of isolation group us-fake1-a: fewer isolation groups than replication factor 3 would remain",
+	}, plan)
+}
+
+func TestPlanClusterActionsBatchExpansion(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Spec.ScalingPolicy.BatchExpansion = true
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	var sets []*appsv1.StatefulSet
+	for _, group := range cluster.Spec.IsolationGroups {
+		set, err := controller.generateStatefulSet(cluster, group.Name, group.NumInstances)
+		require.NoError(t, err)
+		sets = append(sets, set)
+	}
+
+	deps.namespaceClient.EXPECT().List().Return(nil, errors.New("coordinator unavailable"))
+
+	cluster.Spec.IsolationGroups[0].NumInstances = 5
+	cluster.Spec.IsolationGroups[1].NumInstances = 4
+
+	plan, err := controller.planClusterActions(cluster, sets, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"create configmap m3db-config-map-cluster-zones",
+		"create service m3coordinator-cluster-zones",
+		"create service m3dbnode-cluster-zones",
+		"unable to plan namespace changes: coordinator unavailable",
+		"initialize placement",
+		"scale statefulset cluster-zones-us-fake1-a from 3 to 5 replica(s)",
+		"scale statefulset cluster-zones-us-fake1-b from 3 to 4 replica(s)",
+		"add 3 instance(s) to placement in a single operation",
+	}, plan)
+}
+
+func TestPlanScaleStepsRemoveMany(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Spec.ScalingPolicy.MaxInstancesRemovedPerStep = 3
+
+	g := &plannedGroup{
+		name:        "us-fake1-a",
+		setName:     "cluster-zones-us-fake1-a",
+		desired:     1,
+		current:     5,
+		inPlacement: 5,
+	}
+	plan, blocked := g.planScaleSteps(cluster)
+	assert.False(t, blocked)
+	assert.Equal(t, []string{
+		"remove 4 instance(s) of isolation group us-fake1-a from placement, up to 3 per placement change",
+		"scale statefulset cluster-zones-us-fake1-a from 5 to 1 replica(s)",
+	}, plan)
+}
+
+func TestPlanNamespaceActions(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	resource := newNamespaceResource("resource-ns", cluster.Name)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, resource},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	deps.namespaceClient.EXPECT().List().Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{},
+	}, nil)
+
+	// Namespaces defined by M3DBNamespace resources are planned along with
+	// those in the cluster's spec.
+	assert.Equal(t, []string{
+		"create namespace metrics-10s:2d",
+		"create namespace resource-ns",
+	}, controller.planNamespaceActions(cluster))
+}
+
+func TestHandleClusterUpdateDryRun(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Annotations = map[string]string{"operator.m3db.io/dry-run": "true"}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	deps.namespaceClient.EXPECT().List().Return(nil, errors.New("coordinator unavailable"))
+
+	err := controller.handleClusterUpdate(cluster)
+	require.NoError(t, err)
+
+	sets, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, sets.Items)
+
+	cms, err := deps.kubeClient.CoreV1().ConfigMaps(cluster.Namespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, cms.Items)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, cluster.Status.PlannedActions, 8)
+	assert.Equal(t, "create configmap m3db-config-map-cluster-zones", cluster.Status.PlannedActions[0])
+	// Dry run clusters aren't changed, not even by adding a finalizer.
+	assert.Empty(t, cluster.Finalizers)
+
+	// Leaving dry run mode clears the plan.
+	cluster, err = controller.setPlannedActions(cluster, nil)
+	require.NoError(t, err)
+	assert.Empty(t, cluster.Status.PlannedActions)
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	"github.com/m3db/m3/src/cluster/placement"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// podIdentityRetryInterval is how long to wait before retrying to enforce a
+	// pod's new identity while the placement has unavailable instances.
+	podIdentityRetryInterval = 30 * time.Second
+)
+
+// podIdentityChangePolicy returns the cluster's pod identity change policy,
+// defaulting to Ignore.
+func podIdentityChangePolicy(cluster *myspec.M3DBCluster) myspec.PodIdentityChangePolicy {
+	cfg := cluster.Spec.PodIdentityConfig
+	if cfg == nil || cfg.ChangePolicy == "" {
+		return myspec.PodIdentityChangePolicyIgnore
+	}
+	return cfg.ChangePolicy
+}
+
+// identityIncludesUID returns true if a pod's identity includes its UID, and
+// so changes whenever the pod is recreated.
+func identityIncludesUID(cluster *myspec.M3DBCluster) bool {
+	cfg := cluster.Spec.PodIdentityConfig
+	if cfg == nil {
+		// Defaults to pod name and UID.
+		return true
+	}
+
+	for _, src := range cfg.Sources {
+		if src == myspec.PodIdentitySourcePodUID {
+			return true
+		}
+	}
+	return false
+}
+
+// podRestartGate serializes the restarts of pods whose identity includes their
+// UID. It holds, per cluster, the pod that was last restarted.
+type podRestartGate struct {
+	sync.Mutex
+
+	restarted map[string]restartedPod
+}
+
+// restartedPod is a pod that was restarted to pick up a new identity.
+type restartedPod struct {
+	name string
+	uid  types.UID
+}
+
+// enforcePodIdentity moves a pod whose identity changed from oldID to newID:
+// the pod's instance is replaced in the placement, the pod is re-annotated
+// with its new identity and then restarted so that M3DB picks it up. The pod
+// is only restarted once the coordinator has accepted the placement change.
+// If the pod's identity includes its UID the restart gives it yet another
+// identity, so the pod is only restarted and its instance is replaced once,
+// by the cluster's replacement check, when it's recreated. Since the instance
+// can't be replaced first, such restarts are done one pod at a time per
+// cluster.
+func (c *Controller) enforcePodIdentity(cluster *myspec.M3DBCluster, pod *corev1.Pod, oldID, newID string) error {
+	podLogger := c.logger.With(zap.String("pod", pod.Name))
+
+	if cluster.Spec.Paused || c.isDryRun(cluster) {
+		podLogger.Info("not enforcing pod identity of paused or dry run cluster")
+		return nil
+	}
+
+	pl, err := c.adminClient.placementClientForCluster(cluster).Get()
+	if err != nil {
+		return fmt.Errorf("error fetching placement: %v", err)
+	}
+
+	key, err := cache.MetaNamespaceKeyFunc(pod)
+	if err != nil {
+		return err
+	}
+
+	_, oldInPlacement := pl.Instance(oldID)
+	_, newInPlacement := pl.Instance(newID)
+
+	// If the new identity is already in the placement a previous attempt (or
+	// the cluster's replacement check) already replaced the instance.
+	replace := oldInPlacement && !newInPlacement
+	restartOnly := identityIncludesUID(cluster)
+	if replace || restartOnly {
+		for _, inst := range pl.Instances() {
+			if !inst.IsAvailable() {
+				podLogger.Info("waiting for instances to be available before replacing pod",
+					zap.String("instance", inst.ID()))
+				c.podWorkQueue.AddAfter(key, podIdentityRetryInterval)
+				return nil
+			}
+		}
+	}
+
+	if restartOnly {
+		ok, err := c.startPodRestart(cluster, pod, pl)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			podLogger.Info("waiting for the cluster's last restarted pod to be replaced before restarting pod")
+			c.podWorkQueue.AddAfter(key, podIdentityRetryInterval)
+			return nil
+		}
+		podLogger.Info("restarting pod, its instance will be replaced once it's recreated",
+			zap.String("oldID", oldID))
+	} else {
+		if replace {
+			if err := c.replacePodInPlacement(cluster, pl, oldID, pod); err != nil {
+				c.recorder.WarningEvent(cluster, eventer.ReasonFailedToUpdate,
+					"failed to replace pod %s with its new identity: %v", pod.Name, err)
+				return err
+			}
+			c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulUpdate,
+				"replaced instance %s of pod %s with %s", oldID, pod.Name, newID)
+		}
+
+		pod.Annotations[podidentity.AnnotationKeyPodIdentity] = newID
+		pod, err = c.kubeClient.CoreV1().Pods(pod.Namespace).Update(pod)
+		if err != nil {
+			podLogger.Error("error updating pod annotation", zap.Error(err))
+			return err
+		}
+	}
+
+	// M3DB only reads its identity on startup.
+	podLogger.Info("restarting pod with new ID", zap.String("oldID", oldID), zap.String("newID", newID))
+	if err := c.kubeClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+		if restartOnly {
+			c.endPodRestart(cluster)
+		}
+		c.recorder.WarningEvent(pod, eventer.ReasonFailedToUpdate,
+			"failed to restart pod %s with new ID, it must be restarted manually: %v", pod.Name, err)
+		return fmt.Errorf("error deleting pod %s: %v", pod.Name, err)
+	}
+
+	c.recorder.NormalEvent(pod, eventer.ReasonUpdating, "restarted pod %s with new ID", pod.Name)
+	return nil
+}
+
+// startPodRestart records that a pod of a cluster is about to be restarted.
+// It returns false if the pod that was last restarted for the cluster hasn't
+// yet been recreated and had its instance replaced and bootstrapped.
+func (c *Controller) startPodRestart(cluster *myspec.M3DBCluster, pod *corev1.Pod, pl placement.Placement) (bool, error) {
+	key, err := cache.MetaNamespaceKeyFunc(cluster)
+	if err != nil {
+		return false, err
+	}
+
+	c.podRestarts.Lock()
+	defer c.podRestarts.Unlock()
+
+	if c.podRestarts.restarted == nil {
+		c.podRestarts.restarted = make(map[string]restartedPod)
+	}
+	if last, ok := c.podRestarts.restarted[key]; ok {
+		replaced, err := c.podRestartReplaced(cluster, last, pl)
+		if err != nil || !replaced {
+			return false, err
+		}
+	}
+
+	c.podRestarts.restarted[key] = restartedPod{name: pod.Name, uid: pod.UID}
+	return true, nil
+}
+
+// endPodRestart forgets the pod that was last restarted for a cluster.
+func (c *Controller) endPodRestart(cluster *myspec.M3DBCluster) {
+	key, err := cache.MetaNamespaceKeyFunc(cluster)
+	if err != nil {
+		return
+	}
+
+	c.podRestarts.Lock()
+	delete(c.podRestarts.restarted, key)
+	c.podRestarts.Unlock()
+}
+
+// podRestartReplaced returns true once a restarted pod has been recreated and
+// the instance with its new identity is available in the placement.
+func (c *Controller) podRestartReplaced(cluster *myspec.M3DBCluster, restarted restartedPod, pl placement.Placement) (bool, error) {
+	pod, err := c.podLister.Pods(cluster.Namespace).Get(restarted.name)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if pod.UID == restarted.uid {
+		return false, nil
+	}
+
+	id, err := c.podIDProvider.Identity(pod, cluster)
+	if err != nil {
+		return false, err
+	}
+	idStr, err := podidentity.IdentityJSON(id)
+	if err != nil {
+		return false, err
+	}
+
+	inst, ok := pl.Instance(idStr)
+	return ok && inst.IsAvailable(), nil
+}
@@ -0,0 +1,301 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package controller
+
+import (
+	"testing"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ktesting "k8s.io/client-go/testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type podIdentityTestSetup struct {
+	deps       *testDeps
+	controller *Controller
+	cluster    *myspec.M3DBCluster
+	pod        *corev1.Pod
+	pods       []*corev1.Pod
+	pl         placement.Placement
+	oldID      string
+	newID      string
+}
+
+// newPodIdentityTestSetup returns a cluster with a placement of 3 pods, the
+// first of which is annotated with an identity that no longer matches the one
+// computed for it. Identities are sourced from the node name unless sources
+// are given.
+func newPodIdentityTestSetup(
+	t *testing.T,
+	policy myspec.PodIdentityChangePolicy,
+	shardState shard.State,
+	sources ...myspec.PodIdentitySource,
+) *podIdentityTestSetup {
+	if len(sources) == 0 {
+		sources = []myspec.PodIdentitySource{myspec.PodIdentitySourceNodeName}
+	}
+
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Spec.PodIdentityConfig = &myspec.PodIdentityConfig{
+		Sources:      sources,
+		ChangePolicy: policy,
+	}
+
+	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	require.NoError(t, err)
+
+	pods := podsForClusterSet(cluster, set, 3)
+	oldID, err := podidentity.IdentityJSON(identityForPod(pods[0]))
+	require.NoError(t, err)
+	pods[0].Annotations = map[string]string{
+		podidentity.AnnotationKeyPodIdentity: oldID,
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: objectsFromPods(pods...),
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+
+	// The placement holds the pods' old identities.
+	for _, pod := range pods {
+		deps.idProvider.EXPECT().Identity(newPodNameMatcher(pod.Name), gomock.Any()).Return(identityForPod(pod), nil).Times(1)
+	}
+	pl := placementFromPods(t, cluster, pods, deps.idProvider)
+	for _, inst := range pl.Instances() {
+		inst.SetShards(shard.NewShards([]shard.Shard{shard.NewShard(0).SetState(shardState)}))
+	}
+
+	newIdentity := &myspec.PodIdentity{Name: pods[0].Name, NodeName: "node-a"}
+	newID, err := podidentity.IdentityJSON(newIdentity)
+	require.NoError(t, err)
+	deps.idProvider.EXPECT().Identity(newPodNameMatcher(pods[0].Name), gomock.Any()).Return(newIdentity, nil).AnyTimes()
+
+	return &podIdentityTestSetup{
+		deps:       deps,
+		controller: controller,
+		cluster:    cluster,
+		pod:        pods[0],
+		pods:       pods,
+		pl:         pl,
+		oldID:      oldID,
+		newID:      newID,
+	}
+}
+
+func TestHandlePodUpdateIgnoresIdentityChange(t *testing.T) {
+	setup := newPodIdentityTestSetup(t, "", shard.Available)
+	defer setup.deps.cleanup()
+
+	// The placement mock expects no calls.
+	err := setup.controller.handlePodUpdate(setup.pod)
+	require.NoError(t, err)
+
+	pod, err := setup.deps.kubeClient.CoreV1().Pods(setup.pod.Namespace).Get(setup.pod.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, setup.oldID, pod.Annotations[podidentity.AnnotationKeyPodIdentity])
+}
+
+func TestHandlePodUpdateReplacesIdentity(t *testing.T) {
+	setup := newPodIdentityTestSetup(t, myspec.PodIdentityChangePolicyReplace, shard.Available)
+	defer setup.deps.cleanup()
+
+	expInst, err := k8sops.PlacementInstanceFromPod(setup.cluster, setup.pod, setup.deps.idProvider)
+	require.NoError(t, err)
+
+	gomock.InOrder(
+		setup.deps.placementClient.EXPECT().Get().Return(setup.pl, nil),
+		setup.deps.placementClient.EXPECT().Replace(setup.oldID, *expInst),
+	)
+
+	err = setup.controller.handlePodUpdate(setup.pod)
+	require.NoError(t, err)
+
+	// The pod is re-annotated and then restarted.
+	var actions []string
+	for _, action := range setup.deps.kubeClient.Actions() {
+		if action.GetResource().Resource == "pods" && action.GetVerb() != "list" && action.GetVerb() != "watch" {
+			actions = append(actions, action.GetVerb())
+		}
+	}
+	assert.Equal(t, []string{"update", "delete"}, actions)
+
+	_, err = setup.deps.kubeClient.CoreV1().Pods(setup.pod.Namespace).Get(setup.pod.Name, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	cluster, err := setup.deps.crdClient.Operator().M3DBClusters(setup.cluster.Namespace).Get(setup.cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, cluster.Status.HasPodBootstrapping())
+}
+
+func TestHandlePodUpdateRestartsPodWithUIDIdentity(t *testing.T) {
+	setup := newPodIdentityTestSetup(t, myspec.PodIdentityChangePolicyReplace, shard.Available,
+		myspec.PodIdentitySourceNodeName, myspec.PodIdentitySourcePodUID)
+	defer setup.deps.cleanup()
+
+	// The recreated pod will have a new UID, so its instance isn't replaced
+	// with the identity it has now.
+	setup.deps.placementClient.EXPECT().Get().Return(setup.pl, nil)
+
+	err := setup.controller.handlePodUpdate(setup.pod)
+	require.NoError(t, err)
+
+	var actions []string
+	for _, action := range setup.deps.kubeClient.Actions() {
+		if action.GetResource().Resource == "pods" && action.GetVerb() != "list" && action.GetVerb() != "watch" {
+			actions = append(actions, action.GetVerb())
+		}
+	}
+	assert.Equal(t, []string{"delete"}, actions)
+}
+
+func TestHandlePodUpdateRestartsOnePodAtATimeWithUIDIdentity(t *testing.T) {
+	setup := newPodIdentityTestSetup(t, myspec.PodIdentityChangePolicyReplace, shard.Available,
+		myspec.PodIdentitySourceNodeName, myspec.PodIdentitySourcePodUID)
+	defer setup.deps.cleanup()
+
+	// The second pod's identity changed as well.
+	other := setup.pods[1].DeepCopy()
+	otherOldID, err := podidentity.IdentityJSON(identityForPod(other))
+	require.NoError(t, err)
+	other.Annotations = map[string]string{
+		podidentity.AnnotationKeyPodIdentity: otherOldID,
+	}
+	setup.deps.idProvider.EXPECT().Identity(newPodNameMatcher(other.Name), gomock.Any()).
+		Return(&myspec.PodIdentity{Name: other.Name, NodeName: "node-b"}, nil).AnyTimes()
+
+	setup.deps.placementClient.EXPECT().Get().Return(setup.pl, nil).Times(2)
+
+	require.NoError(t, setup.controller.handlePodUpdate(setup.pod))
+	require.NoError(t, setup.controller.handlePodUpdate(other))
+
+	deletedPods := func() []string {
+		var deleted []string
+		for _, action := range setup.deps.kubeClient.Actions() {
+			if action.GetResource().Resource == "pods" && action.GetVerb() == "delete" {
+				deleted = append(deleted, action.(ktesting.DeleteAction).GetName())
+			}
+		}
+		return deleted
+	}
+
+	// Only the first pod is restarted, the second waits for its replacement.
+	assert.Equal(t, []string{setup.pod.Name}, deletedPods())
+
+	// Once the first pod is recreated and its new instance is available the
+	// second pod is restarted.
+	recreated := setup.pod.DeepCopy()
+	recreated.UID = "recreated"
+	recreated.ResourceVersion = ""
+	_, err = setup.deps.kubeClient.CoreV1().Pods(recreated.Namespace).Create(recreated)
+	require.NoError(t, err)
+	require.NoError(t, wait.Poll(time.Millisecond, 5*time.Second, func() (bool, error) {
+		pod, err := setup.deps.podLister.Pods(recreated.Namespace).Get(recreated.Name)
+		return err == nil && pod.UID == recreated.UID, nil
+	}))
+
+	expInst, err := k8sops.PlacementInstanceFromPod(setup.cluster, recreated, setup.deps.idProvider)
+	require.NoError(t, err)
+	newInst, err := placement.NewInstanceFromProto(expInst)
+	require.NoError(t, err)
+	newInst.SetShards(shard.NewShards([]shard.Shard{shard.NewShard(0).SetState(shard.Available)}))
+
+	insts := []placement.Instance{newInst}
+	for _, inst := range setup.pl.Instances() {
+		if inst.ID() != setup.oldID {
+			insts = append(insts, inst)
+		}
+	}
+	setup.deps.placementClient.EXPECT().Get().Return(placement.NewPlacement().SetInstances(insts), nil)
+
+	require.NoError(t, setup.controller.handlePodUpdate(other))
+	assert.Equal(t, []string{setup.pod.Name, other.Name}, deletedPods())
+}
+
+func TestIdentityIncludesUID(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+
+	cluster.Spec.PodIdentityConfig = nil
+	assert.True(t, identityIncludesUID(cluster))
+
+	cluster.Spec.PodIdentityConfig = &myspec.PodIdentityConfig{
+		Sources: []myspec.PodIdentitySource{myspec.PodIdentitySourceNodeName},
+	}
+	assert.False(t, identityIncludesUID(cluster))
+
+	cluster.Spec.PodIdentityConfig.Sources = append(cluster.Spec.PodIdentityConfig.Sources, myspec.PodIdentitySourcePodUID)
+	assert.True(t, identityIncludesUID(cluster))
+}
+
+func TestHandlePodUpdateReplaceWaitsForPlacement(t *testing.T) {
+	setup := newPodIdentityTestSetup(t, myspec.PodIdentityChangePolicyReplace, shard.Initializing)
+	defer setup.deps.cleanup()
+
+	setup.deps.placementClient.EXPECT().Get().Return(setup.pl, nil)
+
+	err := setup.controller.handlePodUpdate(setup.pod)
+	require.NoError(t, err)
+
+	pod, err := setup.deps.kubeClient.CoreV1().Pods(setup.pod.Namespace).Get(setup.pod.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, setup.oldID, pod.Annotations[podidentity.AnnotationKeyPodIdentity])
+}
+
+func TestHandlePodUpdateAlreadyReplaced(t *testing.T) {
+	setup := newPodIdentityTestSetup(t, myspec.PodIdentityChangePolicyReplace, shard.Available)
+	defer setup.deps.cleanup()
+
+	// The pod's instance was already replaced, e.g. by the cluster's replacement
+	// check, so only the annotation and restart remain.
+	expInst, err := k8sops.PlacementInstanceFromPod(setup.cluster, setup.pod, setup.deps.idProvider)
+	require.NoError(t, err)
+	newInst, err := placement.NewInstanceFromProto(expInst)
+	require.NoError(t, err)
+
+	var insts []placement.Instance
+	for _, inst := range setup.pl.Instances() {
+		if inst.ID() != setup.oldID {
+			insts = append(insts, inst)
+		}
+	}
+	pl := placement.NewPlacement().SetInstances(append(insts, newInst))
+	setup.deps.placementClient.EXPECT().Get().Return(pl, nil)
+
+	err = setup.controller.handlePodUpdate(setup.pod)
+	require.NoError(t, err)
+
+	_, err = setup.deps.kubeClient.CoreV1().Pods(setup.pod.Namespace).Get(setup.pod.Name, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
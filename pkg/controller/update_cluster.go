@@ -28,48 +28,61 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
 	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
 	"github.com/m3db/m3db-operator/pkg/m3admin"
 	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
 	"github.com/m3db/m3db-operator/pkg/util/eventer"
 
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
 	"github.com/m3db/m3/src/cluster/placement"
 	dbns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/query/generated/proto/admin"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
 
+	"github.com/kubernetes/utils/pointer"
 	"go.uber.org/zap"
 )
 
+const defaultNamespaceDeletionGracePeriod = time.Hour
+
 // reconcileNamespaces will delete any namespaces currently in the cluster that
-// aren't part of the cluster spec, and create any that are present in the spec
-// but not in the cluster.
-func (c *Controller) reconcileNamespaces(cluster *myspec.M3DBCluster) error {
+// aren't part of the cluster spec or of an M3DBNamespace, create any that are
+// present in the spec but not in the cluster, and update any whose options
+// differ from the spec. Returns the cluster with any status updates applied.
+func (c *Controller) reconcileNamespaces(cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, error) {
 	resp, err := c.adminClient.namespaceClientForCluster(cluster).List()
 	if err != nil {
 		c.logger.Error("failed to get namespace", zap.Error(err))
 		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, err.Error())
-		return err
+		return nil, err
 	}
 
-	if err := c.pruneNamespaces(cluster, resp.Registry); err != nil {
-		return err
+	cluster, err = c.pruneNamespaces(cluster, resp.Registry)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := c.createNamespaces(cluster, resp.Registry); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return c.updateNamespaces(cluster, resp.Registry)
 }
 
 // createNamespaces will attempt to create in the cluster all namespaces which
@@ -101,12 +114,152 @@ func (c *Controller) createNamespaces(cluster *myspec.M3DBCluster, registry *dbn
 	return nil
 }
 
+// updateNamespaces will attempt to update any namespaces in the cluster whose
+// options differ from the spec. If any options that can't be changed for an
+// existing namespace differ then none of the namespace's options are updated,
+// and the namespace is reported through a warning event and the
+// NamespaceUpdateBlocked condition.
+func (c *Controller) updateNamespaces(cluster *myspec.M3DBCluster, registry *dbns.Registry) (*myspec.M3DBCluster, error) {
+	var blocked []string
+	for _, ns := range cluster.Spec.Namespaces {
+		current, ok := registry.Namespaces[ns.Name]
+		if !ok {
+			continue
+		}
+
+		req, err := namespace.RequestFromSpec(ns)
+		if err != nil {
+			c.logger.Error("error forming namespace request",
+				zap.String("namespace", ns.Name),
+				zap.Error(err))
+
+			return nil, fmt.Errorf("error forming request for namespace '%s': %v", ns.Name, err)
+		}
+
+		changed, immutable := namespace.OptionsDiff(current, req.Options)
+		if len(immutable) > 0 {
+			msg := fmt.Sprintf("cannot update namespace %s: %s cannot be changed", ns.Name,
+				strings.Join(immutable, ", "))
+			c.logger.Warn(msg)
+			blocked = append(blocked, msg)
+			continue
+		}
+
+		if len(changed) == 0 {
+			continue
+		}
+
+		err = c.adminClient.namespaceClientForCluster(cluster).Update(req)
+		if err != nil {
+			c.logger.Error("error updating namespace",
+				zap.String("namespace", ns.Name),
+				zap.Error(err))
+
+			return nil, fmt.Errorf("error updating namespace '%s': %v", ns.Name, err)
+		}
+
+		c.logger.Info("updated namespace", zap.String("namespace", ns.Name), zap.Strings("fields", changed))
+		c.recorder.NormalEvent(cluster, eventer.ReasonUpdating, "updated namespace %s: %s", ns.Name,
+			strings.Join(changed, ", "))
+	}
+
+	return c.setNamespaceUpdateStatus(cluster, blocked)
+}
+
+// setNamespaceUpdateStatus sets the NamespaceUpdateBlocked condition if any
+// namespace updates are blocked, and clears it once none are. The status is
+// only written, and a warning event only emitted for blocked updates, if the
+// condition changes.
+func (c *Controller) setNamespaceUpdateStatus(cluster *myspec.M3DBCluster, blocked []string) (*myspec.M3DBCluster, error) {
+	status := corev1.ConditionFalse
+	reason, message := "NamespacesUpdated", "all namespaces match the spec"
+	if len(blocked) > 0 {
+		status = corev1.ConditionTrue
+		reason, message = "ImmutableOptionsChanged", strings.Join(blocked, "; ")
+	}
+
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionNamespaceUpdateBlocked)
+	if !ok && status == corev1.ConditionFalse {
+		return cluster, nil
+	}
+	if ok && cond.Status == status && cond.Message == message {
+		return cluster, nil
+	}
+
+	if status == corev1.ConditionTrue {
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailedToUpdate, message)
+	}
+
+	return c.setStatus(cluster, myspec.ClusterConditionNamespaceUpdateBlocked, status, reason, message)
+}
+
 // pruneNamespaces will delete any namespaces in the m3db cluster that aren't
-// in the spec.
-func (c *Controller) pruneNamespaces(cluster *myspec.M3DBCluster, registry *dbns.Registry) error {
-	toDelete := namespacesToDelete(registry, cluster.Spec.Namespaces)
+// in the spec or an M3DBNamespace and that the cluster allows to be deleted.
+// Namespaces are only deleted once they've been absent for the cluster's grace
+// period, and until then are listed in the cluster's status as pending
+// deletion. Returns the cluster with its updated status.
+func (c *Controller) pruneNamespaces(cluster *myspec.M3DBCluster, registry *dbns.Registry) (*myspec.M3DBCluster, error) {
+	desired, err := c.desiredNamespaces(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	toDelete := namespacesToDelete(registry, desired)
+	sort.Strings(toDelete)
+
+	pending := make(map[string]myspec.PendingNamespaceDeletion)
+	for _, p := range cluster.Status.PendingNamespaceDeletions {
+		pending[p.Name] = p
+	}
+
+	var (
+		now          = c.clock.Now()
+		grace        = namespaceDeletionGracePeriod(cluster)
+		stillPending []myspec.PendingNamespaceDeletion
+		deleteErr    error
+	)
+
 	for _, ns := range toDelete {
-		err := c.adminClient.namespaceClientForCluster(cluster).Delete(ns)
+		if !namespaceDeletionAllowed(cluster, ns) {
+			c.logger.Info("retaining namespace not in spec", zap.String("namespace", ns))
+			continue
+		}
+
+		p, ok := pending[ns]
+		delete(pending, ns)
+
+		deleteAfter, err := time.Parse(time.RFC3339, p.DeleteAfter)
+		if !ok || err != nil {
+			deleteAfter = now.Add(grace)
+			p = myspec.PendingNamespaceDeletion{
+				Name:        ns,
+				DeleteAfter: deleteAfter.UTC().Format(time.RFC3339),
+			}
+			if grace > 0 {
+				c.logger.Info("scheduling namespace deletion", zap.String("namespace", ns),
+					zap.String("deleteAfter", p.DeleteAfter))
+				c.recorder.WarningEvent(cluster, eventer.ReasonDeleting,
+					"namespace %s was removed from the spec and will be deleted after %s", ns, p.DeleteAfter)
+			}
+		}
+
+		if remaining := deleteAfter.Sub(now); remaining > 0 {
+			stillPending = append(stillPending, p)
+
+			// Nothing else may trigger a sync of the cluster when the grace
+			// period expires, so check back then.
+			if key, err := cache.MetaNamespaceKeyFunc(cluster); err == nil {
+				c.clusterWorkQueue.AddAfter(key, remaining)
+			}
+			continue
+		}
+
+		if deleteErr != nil {
+			stillPending = append(stillPending, p)
+			continue
+		}
+
+		err = c.adminClient.namespaceClientForCluster(cluster).Delete(ns)
 		if err == nil {
 			c.logger.Info("deleted namespace", zap.String("namespace", ns))
 			c.recorder.NormalEvent(cluster, eventer.ReasonDeleting, "deleted namespace "+ns)
@@ -121,10 +274,56 @@ func (c *Controller) pruneNamespaces(cluster *myspec.M3DBCluster, registry *dbns
 		c.logger.Error("error deleting namespace",
 			zap.String("namespace", ns),
 			zap.Error(err))
-		return err
+		stillPending = append(stillPending, p)
+		deleteErr = err
 	}
 
-	return nil
+	// Anything left was either restored to the spec or is no longer allowed to
+	// be deleted.
+	for ns := range pending {
+		c.logger.Info("cancelled namespace deletion", zap.String("namespace", ns))
+		c.recorder.NormalEvent(cluster, eventer.ReasonDeleting, "cancelled deletion of namespace "+ns)
+	}
+
+	if !reflect.DeepEqual(stillPending, cluster.Status.PendingNamespaceDeletions) {
+		cluster, err = c.updateStatus(cluster, func(status *myspec.M3DBStatus) {
+			status.PendingNamespaceDeletions = stillPending
+		})
+		if err != nil {
+			c.logger.Error("error updating pending namespace deletions", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	if deleteErr != nil {
+		return nil, deleteErr
+	}
+
+	return cluster, nil
+}
+
+// namespaceDeletionAllowed returns whether a namespace may be deleted from the
+// cluster once it's removed from the spec, either because the cluster's policy
+// allows it or because the namespace is listed in the cluster's annotation.
+func namespaceDeletionAllowed(cluster *myspec.M3DBCluster, ns string) bool {
+	if cluster.Spec.NamespaceDeletionPolicy == myspec.NamespaceDeletionPolicyDelete {
+		return true
+	}
+
+	for _, name := range strings.Split(cluster.Annotations[annotations.DeleteNamespaces], ",") {
+		if strings.TrimSpace(name) == ns {
+			return true
+		}
+	}
+
+	return false
+}
+
+func namespaceDeletionGracePeriod(cluster *myspec.M3DBCluster) time.Duration {
+	if secs := cluster.Spec.NamespaceDeletionGracePeriodSeconds; secs != nil {
+		return time.Duration(*secs) * time.Second
+	}
+	return defaultNamespaceDeletionGracePeriod
 }
 
 // namespacesToCreate returns an array of namespaces that are in the cluster
@@ -179,7 +378,7 @@ func (c *Controller) validatePlacementWithStatus(cluster *myspec.M3DBCluster) (*
 	// Error is just that placement isn't there, let's create it.
 
 	newPlacement := &admin.PlacementInitRequest{
-		NumShards:         cluster.Spec.NumberOfShards,
+		NumShards:         k8sops.NumberOfShards(cluster),
 		ReplicationFactor: cluster.Spec.ReplicationFactor,
 	}
 
@@ -213,18 +412,15 @@ func (c *Controller) validatePlacementWithStatus(cluster *myspec.M3DBCluster) (*
 }
 
 func (c *Controller) setStatusPlacementCreated(cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, error) {
-	cluster.Status.UpdateCondition(myspec.ClusterCondition{
-		Type:           myspec.ClusterConditionPlacementInitialized,
-		Status:         corev1.ConditionTrue,
-		LastUpdateTime: c.clock.Now().UTC().Format(time.RFC3339),
-		Reason:         "PlacementCreated",
-		Message:        "Created placement",
+	updated, err := c.updateStatus(cluster, func(status *myspec.M3DBStatus) {
+		status.UpdateCondition(myspec.ClusterCondition{
+			Type:           myspec.ClusterConditionPlacementInitialized,
+			Status:         corev1.ConditionTrue,
+			LastUpdateTime: c.clock.Now().UTC().Format(time.RFC3339),
+			Reason:         "PlacementCreated",
+			Message:        "Created placement",
+		})
 	})
-
-	// TODO(schallert): move to UpdateStatus once 1.10 status subresource is out
-	// of alpha.
-	var err error
-	cluster, err = c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster)
 	if err != nil {
 		err := fmt.Errorf("error updating cluster placement init status: %v", err)
 		c.logger.Error(err.Error())
@@ -232,9 +428,9 @@ func (c *Controller) setStatusPlacementCreated(cluster *myspec.M3DBCluster) (*my
 		return nil, err
 	}
 
-	c.logger.Info("updated cluster placement status", zap.String("cluster", cluster.Name),
-		zap.Any("status", cluster.Status))
-	return cluster, nil
+	c.logger.Info("updated cluster placement status", zap.String("cluster", updated.Name),
+		zap.Any("status", updated.Status))
+	return updated, nil
 }
 
 func (c *Controller) setStatusPodBootstrapping(cluster *myspec.M3DBCluster,
@@ -247,26 +443,68 @@ func (c *Controller) setStatusPodBootstrapping(cluster *myspec.M3DBCluster,
 func (c *Controller) setStatus(cluster *myspec.M3DBCluster, condition myspec.ClusterConditionType,
 	status corev1.ConditionStatus, reason, message string) (*myspec.M3DBCluster, error) {
 
-	cond, ok := cluster.Status.GetCondition(condition)
-	if !ok {
-		cond = myspec.ClusterCondition{
-			Type:   condition,
-			Status: corev1.ConditionUnknown,
+	return c.updateStatus(cluster, func(clusterStatus *myspec.M3DBStatus) {
+		cond, ok := clusterStatus.GetCondition(condition)
+		if !ok {
+			cond = myspec.ClusterCondition{
+				Type:   condition,
+				Status: corev1.ConditionUnknown,
+			}
 		}
-	}
 
-	curTime := c.clock.Now().UTC().Format(time.RFC3339)
-	if cond.Status != status {
-		cond.LastTransitionTime = curTime
+		curTime := c.clock.Now().UTC().Format(time.RFC3339)
+		if cond.Status != status {
+			cond.LastTransitionTime = curTime
+		}
+
+		cond.Status = status
+		cond.LastUpdateTime = curTime
+		cond.Reason = reason
+		cond.Message = message
+		clusterStatus.UpdateCondition(cond)
+	})
+}
+
+// updateStatus applies mutate to the cluster's status and writes it through
+// the status subresource, leaving the spec untouched. If the write conflicts
+// with a concurrent update the latest version of the cluster is fetched and
+// mutate is applied to it again.
+func (c *Controller) updateStatus(
+	cluster *myspec.M3DBCluster,
+	mutate func(status *myspec.M3DBStatus),
+) (*myspec.M3DBCluster, error) {
+	client := c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace)
+	current := cluster.DeepCopy()
+
+	var updated *myspec.M3DBCluster
+	err := retryStatusUpdate(func() (err error) {
+		mutate(&current.Status)
+		updated, err = client.UpdateStatus(current)
+		return err
+	}, func() (err error) {
+		current, err = client.Get(cluster.Name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	cond.Status = status
-	cond.LastUpdateTime = curTime
-	cond.Reason = reason
-	cond.Message = message
-	cluster.Status.UpdateCondition(cond)
+	return updated, nil
+}
 
-	return c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster)
+// retryStatusUpdate calls update until it succeeds or fails with an error other
+// than a conflict. After each conflict refresh is called to fetch the latest
+// version of the object, which update is then applied to.
+func retryStatusUpdate(update func() error, refresh func() error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := update()
+		if kerrors.IsConflict(err) {
+			if refreshErr := refresh(); refreshErr != nil {
+				return refreshErr
+			}
+		}
+		return err
+	})
 }
 
 // Updates the cluster if there had been a condition that a pod was
@@ -402,34 +640,153 @@ func (c *Controller) expandPlacementForSet(cluster *myspec.M3DBCluster, set *app
 		return fmt.Errorf("cannot expand set '%s', not yet ready", set.Name)
 	}
 
+	pods, err := c.podsAbsentFromPlacement(cluster, set, placement)
+	if err != nil {
+		return err
+	}
+
+	if len(pods) == 0 {
+		return errors.New("could not find pod absent from placement")
+	}
+
+	return c.addPodToPlacement(cluster, pods[0])
+}
+
+// podsAbsentFromPlacement returns the pods of a set whose identity doesn't
+// match any instance in the placement.
+func (c *Controller) podsAbsentFromPlacement(cluster *myspec.M3DBCluster, set *appsv1.StatefulSet,
+	pl placement.Placement) ([]*corev1.Pod, error) {
+
 	selector := klabels.SelectorFromSet(set.Labels)
 	pods, err := c.podLister.Pods(cluster.Namespace).List(selector)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var absent []*corev1.Pod
 	for _, pod := range pods {
 		id, err := c.podIDProvider.Identity(pod, cluster)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		idStr, err := podidentity.IdentityJSON(id)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if _, ok := pl.Instance(idStr); !ok {
+			absent = append(absent, pod)
 		}
-		_, ok := placement.Instance(idStr)
+	}
+
+	sort.Slice(absent, func(i, j int) bool {
+		return absent[i].Name < absent[j].Name
+	})
+	return absent, nil
+}
+
+// expandPlacementBatch grows the isolation groups of a cluster that opts in to
+// batch expansion. Sets below their desired size are scaled straight to it, and
+// once every set is at its desired size all of their pods absent from the
+// placement are added to it in a single operation. Returns true if any changes
+// were made.
+func (c *Controller) expandPlacementBatch(
+	cluster *myspec.M3DBCluster,
+	sets []*appsv1.StatefulSet,
+	groups []myspec.IsolationGroup,
+	pl placement.Placement,
+) (bool, error) {
+	scaled := false
+	for _, set := range sets {
+		group, ok := myspec.IsolationGroups(groups).GetByName(set.Labels[labels.IsolationGroup])
 		if !ok {
-			return c.addPodToPlacement(cluster, pod)
+			continue
+		}
+
+		if set.Spec.Replicas == nil {
+			return false, fmt.Errorf("set %s has unset spec replica", set.Name)
+		}
+
+		current := *set.Spec.Replicas
+		if current >= group.NumInstances {
+			continue
+		}
+
+		c.logger.Info("resizing set to desired size",
+			zap.String("statefulSet", set.Name),
+			zap.Int32("current", current),
+			zap.Int32("desired", group.NumInstances))
+
+		set = set.DeepCopy()
+		set.Spec.Replicas = pointer.Int32Ptr(group.NumInstances)
+		if _, err := c.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set); err != nil {
+			return false, fmt.Errorf("error updating statefulset %s: %v", set.Name, err)
+		}
+		scaled = true
+	}
+
+	// Wait for all new pods to be ready before adding them.
+	if scaled {
+		return true, nil
+	}
+
+	var (
+		insts []placementpb.Instance
+		names []string
+	)
+	for _, set := range sets {
+		group, ok := myspec.IsolationGroups(groups).GetByName(set.Labels[labels.IsolationGroup])
+		if !ok || *set.Spec.Replicas != group.NumInstances {
+			continue
+		}
+
+		if len(instancesInIsoGroup(pl, group.Name)) >= int(group.NumInstances) {
+			continue
+		}
+
+		pods, err := c.podsAbsentFromPlacement(cluster, set, pl)
+		if err != nil {
+			return false, err
+		}
+
+		for _, pod := range pods {
+			inst, err := k8sops.PlacementInstanceFromPod(cluster, pod, c.podIDProvider)
+			if err != nil {
+				return false, fmt.Errorf("error creating instance for pod %s: %v", pod.Name, err)
+			}
+			insts = append(insts, *inst)
+			names = append(names, pod.Name)
 		}
 	}
 
-	return errors.New("could not find pod absent from placement")
+	if len(insts) == 0 {
+		return false, nil
+	}
+
+	reason := fmt.Sprintf("adding %d pods to placement", len(insts))
+	if _, err := c.setStatusPodBootstrapping(cluster, corev1.ConditionTrue, "PodAdded", reason); err != nil {
+		return false, fmt.Errorf("error setting pod bootstrapping status: %v", err)
+	}
+
+	if err := c.adminClient.placementClientForCluster(cluster).AddMany(insts); err != nil {
+		return false, fmt.Errorf("error adding pods to placement: %v", err)
+	}
+
+	c.logger.Info("added pods to placement", zap.Strings("pods", names))
+	c.recorder.NormalEvent(cluster, eventer.ReasonAdding, "added %d pods to placement", len(insts))
+	return true, nil
 }
 
-// shrinkPlacementForSet takes a StatefulSet that needs to be shrunk and
-// removes the last pod in the StatefulSet from the active placement, enabling
-// the StatefulSet size to be decreased once the remove completes.
-func (c *Controller) shrinkPlacementForSet(cluster *myspec.M3DBCluster, set *appsv1.StatefulSet) error {
+// shrinkPlacementForSet takes a StatefulSet that needs to be shrunk to desired
+// pods and removes up to count of its last pods that are still in the active
+// placement in a single placement change, enabling the StatefulSet size to be
+// decreased once the remove completes.
+func (c *Controller) shrinkPlacementForSet(
+	cluster *myspec.M3DBCluster,
+	set *appsv1.StatefulSet,
+	pl placement.Placement,
+	desired int32,
+	count int,
+) error {
 	selector := klabels.SelectorFromSet(set.Labels)
 	pods, err := c.podLister.Pods(cluster.Namespace).List(selector)
 	if err != nil {
@@ -437,30 +794,761 @@ func (c *Controller) shrinkPlacementForSet(cluster *myspec.M3DBCluster, set *app
 		return err
 	}
 
-	removePod, err := findPodToRemove(pods)
+	// Only the pods that will be deleted when the set is scaled down to its
+	// desired size are removed.
+	candidates, err := findPodsToRemove(pods, len(pods)-int(desired))
 	if err != nil {
-		c.logger.Error("error finding pod to remove", zap.Error(err))
+		c.logger.Error("error finding pods to remove", zap.Error(err))
 		return err
 	}
 
-	removePodID, err := c.podIDProvider.Identity(removePod, cluster)
+	ids := make([]string, 0, count)
+	names := make([]string, 0, count)
+	for _, pod := range candidates {
+		if len(ids) == count {
+			break
+		}
+
+		podID, err := c.podIDProvider.Identity(pod, cluster)
+		if err != nil {
+			return err
+		}
+
+		idStr, err := podidentity.IdentityJSON(podID)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := pl.Instance(idStr); !ok {
+			// Removed in an earlier step, waiting for the set to be scaled down.
+			continue
+		}
+
+		ids = append(ids, idStr)
+		names = append(names, pod.Name)
+	}
+
+	if len(ids) == 0 {
+		return fmt.Errorf("no pods of set %s above %d are in the placement", set.Name, desired)
+	}
+
+	plClient := c.adminClient.placementClientForCluster(cluster)
+	c.logger.Info("removing pods from placement", zap.Strings("pods", names))
+	if len(ids) == 1 {
+		return plClient.Remove(ids[0])
+	}
+
+	err = plClient.RemoveMany(ids)
+	if err == m3admin.ErrNotFound {
+		// The coordinator doesn't serve the remove endpoint, remove a single
+		// instance instead.
+		c.logger.Warn("coordinator can't remove several instances at once, removing one",
+			zap.String("pod", names[0]))
+		return plClient.Remove(ids[0])
+	}
+	return err
+}
+
+// maxInstancesRemovedPerStep returns the maximum number of instances that may
+// be removed from an isolation group in a single step.
+func maxInstancesRemovedPerStep(cluster *myspec.M3DBCluster) int32 {
+	if n := cluster.Spec.ScalingPolicy.MaxInstancesRemovedPerStep; n > 1 {
+		return n
+	}
+	return 1
+}
+
+// instancesToRemove returns how many instances are removed from the placement
+// in a single step when shrinking an isolation group that has inPlacement
+// instances to desired instances.
+func instancesToRemove(cluster *myspec.M3DBCluster, desired, inPlacement int32) int32 {
+	count := inPlacement - desired
+	if limit := maxInstancesRemovedPerStep(cluster); count > limit {
+		count = limit
+	}
+	return count
+}
+
+// shrinkWouldBreakReplication returns true if shrinking an isolation group to
+// desired instances would leave fewer isolation groups with instances than the
+// cluster's replication factor, in which case shards could not be fully
+// replicated across isolation groups.
+func shrinkWouldBreakReplication(cluster *myspec.M3DBCluster, desired int32) bool {
+	if desired > 0 {
+		return false
+	}
+
+	var groups int32
+	for _, group := range cluster.Spec.DesiredIsolationGroups() {
+		if group.NumInstances > 0 {
+			groups++
+		}
+	}
+	return groups < cluster.Spec.ReplicationFactor
+}
+
+// shrinkBlockedReason returns why an isolation group can't be shrunk to zero
+// instances.
+func shrinkBlockedReason(cluster *myspec.M3DBCluster, group string) string {
+	return fmt.Sprintf("cannot remove all instances of isolation group %s: fewer isolation groups than replication factor %d would remain",
+		group, cluster.Spec.ReplicationFactor)
+}
+
+// scaleStep is a single step taken to bring an isolation group's set, and its
+// instances in the placement, to the group's desired size.
+type scaleStep int
+
+const (
+	scaleStepNone scaleStep = iota
+	// scaleStepAddInstance adds a pod of the set to the placement.
+	scaleStepAddInstance
+	// scaleStepRemoveInstance removes up to maxInstancesRemovedPerStep of the
+	// set's last pods from the placement.
+	scaleStepRemoveInstance
+	// scaleStepRemoveBlocked means the group's instances must be removed from
+	// the placement but doing so would break replication.
+	scaleStepRemoveBlocked
+	// scaleStepResizeSet grows the set by one pod, or shrinks it by up to
+	// maxInstancesRemovedPerStep pods.
+	scaleStepResizeSet
+)
+
+// nextScaleStep returns the next step for an isolation group of desired
+// instances whose set has current replicas, inPlacement of which are in the
+// placement, and the set's size after the step. A growing set is resized one
+// pod at a time before its new pods are added to the placement, and a
+// shrinking set's pods are removed from the placement, up to
+// maxInstancesRemovedPerStep at a time, before the set is resized.
+func nextScaleStep(cluster *myspec.M3DBCluster, desired, current, inPlacement int32) (scaleStep, int32) {
+	if desired == current {
+		// If the set is at its desired size, and all pods in the set are in the
+		// placement, there's nothing we need to do for this set.
+		if current == inPlacement {
+			return scaleStepNone, current
+		}
+
+		// If the set is at its desired size but there's pods in the set that are
+		// absent from the placement, add pods to placement.
+		if inPlacement < current {
+			return scaleStepAddInstance, current
+		}
+	}
+
+	// If there are more pods in the placement than we want in the group,
+	// trigger a remove so that we can shrink the set.
+	if inPlacement > desired {
+		if shrinkWouldBreakReplication(cluster, desired) {
+			return scaleStepRemoveBlocked, current
+		}
+		return scaleStepRemoveInstance, current
+	}
+
+	if current < desired {
+		return scaleStepResizeSet, current + 1
+	}
+
+	// Pods above the desired size are no longer in the placement, so the set
+	// can shrink by as many pods as are removed from the placement in a step.
+	newCount := current - maxInstancesRemovedPerStep(cluster)
+	if newCount < desired {
+		newCount = desired
+	}
+	return scaleStepResizeSet, newCount
+}
+
+// removedGroupsBlockedReason returns why the sets of removed isolation groups
+// can't be decommissioned, if they can't.
+func removedGroupsBlockedReason(cluster *myspec.M3DBCluster) (string, bool) {
+	numGroups := len(cluster.Spec.IsolationGroups)
+	if rf := int(cluster.Spec.ReplicationFactor); numGroups < rf {
+		return fmt.Sprintf("cannot remove isolation groups: %d remaining groups is fewer than replication factor %d", numGroups, rf), true
+	}
+	return "", false
+}
+
+// generateStatefulSet generates the desired statefulset for an isolation group,
+// including the hash of the configuration mounted by the cluster's pods.
+func (c *Controller) generateStatefulSet(
+	cluster *myspec.M3DBCluster,
+	isoGroup string,
+	instances int32,
+	opts ...k8sops.StatefulSetOption,
+) (*appsv1.StatefulSet, error) {
+	cmName, err := k8sops.ConfigMapName(cluster)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	idStr, err := podidentity.IdentityJSON(removePodID)
+	cm, err := c.configMapLister.ConfigMaps(cluster.Namespace).Get(cmName)
+	if kerrors.IsNotFound(err) {
+		// The default configmap may have just been created and not yet be
+		// reflected in the cache.
+		cm, err = c.kubeClient.CoreV1().ConfigMaps(cluster.Namespace).Get(cmName, metav1.GetOptions{})
+	}
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error fetching configmap %s: %v", cmName, err)
+	}
+
+	opts = append(opts, k8sops.WithConfigHash(k8sops.ConfigMapHash(cm)))
+	return k8sops.GenerateStatefulSet(cluster, isoGroup, instances, opts...)
+}
+
+// updateStatefulSetTemplates updates the pod template of the first set whose
+// template hash doesn't match the template generated from the cluster spec.
+// Sets use the OnDelete update strategy so updating the template does not
+// restart any pods; that is left to restartOutdatedPods. Returns true if a set
+// was updated.
+func (c *Controller) updateStatefulSetTemplates(cluster *myspec.M3DBCluster, sets []*appsv1.StatefulSet) (bool, error) {
+	for _, set := range sets {
+		expected, outdated, err := c.statefulSetTemplateOutdated(cluster, set)
+		if err != nil {
+			return false, err
+		}
+		if !outdated {
+			continue
+		}
+
+		expectedHash := expected.Annotations[annotations.PodTemplateHash]
+
+		c.logger.Info("updating statefulset pod template",
+			zap.String("statefulSet", set.Name),
+			zap.String("currentHash", set.Annotations[annotations.PodTemplateHash]),
+			zap.String("newHash", expectedHash))
+
+		set.Spec.Template = expected.Spec.Template
+		set.Spec.UpdateStrategy = expected.Spec.UpdateStrategy
+		if set.Annotations == nil {
+			set.Annotations = make(map[string]string)
+		}
+		set.Annotations[annotations.PodTemplateHash] = expectedHash
+
+		if _, err := c.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set); err != nil {
+			c.recorder.WarningEvent(cluster, eventer.ReasonFailedToUpdate, "failed to update statefulset %s: %v", set.Name, err)
+			return false, fmt.Errorf("error updating statefulset %s: %v", set.Name, err)
+		}
+
+		c.recorder.NormalEvent(cluster, eventer.ReasonUpdating, "updated pod template of statefulset %s", set.Name)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// statefulSetTemplateOutdated returns the set generated from the cluster spec
+// for an existing set, and whether the existing set's pod template differs
+// from it.
+func (c *Controller) statefulSetTemplateOutdated(
+	cluster *myspec.M3DBCluster,
+	set *appsv1.StatefulSet,
+) (*appsv1.StatefulSet, bool, error) {
+	isoGroup, ok := set.Labels[labels.IsolationGroup]
+	if !ok {
+		return nil, false, fmt.Errorf("statefulset %s has no isolation-group label", set.Name)
+	}
+
+	if set.Spec.Replicas == nil {
+		return nil, false, fmt.Errorf("set %s has unset spec replica", set.Name)
+	}
+
+	// Sets created before sets were named by isolation group keep their
+	// original name.
+	expected, err := c.generateStatefulSet(cluster, isoGroup, *set.Spec.Replicas,
+		k8sops.WithStatefulSetName(set.Name))
+	if err != nil {
+		return nil, false, err
+	}
+
+	outdated := set.Annotations[annotations.PodTemplateHash] != expected.Annotations[annotations.PodTemplateHash]
+	return expected, outdated, nil
+}
+
+// restartOutdatedPods deletes the lowest ordinal pod, across all sets, that is
+// not running its set's current template revision so that it is recreated
+// with the updated template. At most one pod is deleted per call, and none are
+// deleted while any of the cluster's pods is waiting to be replaced in the
+// placement, e.g. because a previously restarted pod came back with a new
+// identity. Returns true if a pod was deleted or if we're waiting on a set to
+// observe its update or on a replacement.
+func (c *Controller) restartOutdatedPods(
+	cluster *myspec.M3DBCluster,
+	sets []*appsv1.StatefulSet,
+	pods []*corev1.Pod,
+	pl placement.Placement,
+) (bool, error) {
+	leavingInstanceID, podToReplace, err := c.checkPodsForReplacement(cluster, pods, pl)
+	if err != nil {
+		return false, err
+	}
+	if podToReplace != nil {
+		c.logger.Info("waiting for pod to be replaced in placement before restarting pods",
+			zap.String("pod", podToReplace.Name),
+			zap.String("instance", leavingInstanceID))
+		return true, nil
+	}
+
+	for _, set := range sets {
+		if set.Status.ObservedGeneration < set.Generation || set.Status.UpdateRevision == "" {
+			// The StatefulSet controller hasn't yet computed the revision for the
+			// latest template, wait for the next set update.
+			c.logger.Info("waiting for statefulset to observe update", zap.String("statefulSet", set.Name))
+			return true, nil
+		}
+
+		setPods, err := c.podLister.Pods(cluster.Namespace).List(klabels.SelectorFromSet(set.Labels))
+		if err != nil {
+			return false, err
+		}
+
+		if len(setPods) == 0 {
+			continue
+		}
+
+		sortedPods, err := sortPods(setPods)
+		if err != nil {
+			return false, fmt.Errorf("cannot sort pods: %v", err)
+		}
+
+		for _, p := range sortedPods {
+			pod := p.pod
+			if pod.Labels[appsv1.ControllerRevisionHashLabelKey] == set.Status.UpdateRevision {
+				continue
+			}
+
+			reason := fmt.Sprintf("restarting pod %s to update to revision %s", pod.Name, set.Status.UpdateRevision)
+			if _, err := c.setStatusPodBootstrapping(cluster, corev1.ConditionTrue, "PodUpdated", reason); err != nil {
+				err := fmt.Errorf("error setting pod bootstrapping status: %v", err)
+				c.logger.Error(err.Error())
+				return false, err
+			}
+
+			c.logger.Info("deleting pod with outdated revision",
+				zap.String("pod", pod.Name),
+				zap.String("revision", pod.Labels[appsv1.ControllerRevisionHashLabelKey]),
+				zap.String("updateRevision", set.Status.UpdateRevision))
+
+			if err := c.kubeClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+				c.recorder.WarningEvent(cluster, eventer.ReasonFailedToUpdate, "failed to restart pod %s: %v", pod.Name, err)
+				return false, fmt.Errorf("error deleting pod %s: %v", pod.Name, err)
+			}
+
+			c.recorder.NormalEvent(cluster, eventer.ReasonUpdating, reason)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// stuckPodTracker holds state about pods on failed nodes that has to survive
+// between reconciles. The zero value is ready to use.
+type stuckPodTracker struct {
+	sync.Mutex
+
+	// nodesMissingSince records when a node was first found to be gone.
+	nodesMissingSince map[string]time.Time
+	// forcedReplacements holds pods deleted by replaceStuckPod, keyed by
+	// namespace/name, whose placement instances must be replaced once they're
+	// recreated.
+	forcedReplacements map[string]forcedReplacement
+}
+
+// forcedReplacement is the placement instance of a pod that was force deleted
+// and the UID of the deleted pod.
+type forcedReplacement struct {
+	instanceID string
+	podUID     types.UID
+}
+
+// nodeMissingSince returns the time node was first found to be gone, which is
+// now if it wasn't already known to be gone.
+func (t *stuckPodTracker) nodeMissingSince(node string, now time.Time) time.Time {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.nodesMissingSince == nil {
+		t.nodesMissingSince = make(map[string]time.Time)
+	}
+	since, ok := t.nodesMissingSince[node]
+	if !ok {
+		since = now
+		t.nodesMissingSince[node] = since
+	}
+	return since
+}
+
+func (t *stuckPodTracker) nodeFound(node string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.nodesMissingSince, node)
+}
+
+func (t *stuckPodTracker) addForcedReplacement(podKey string, r forcedReplacement) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.forcedReplacements == nil {
+		t.forcedReplacements = make(map[string]forcedReplacement)
+	}
+	t.forcedReplacements[podKey] = r
+}
+
+func (t *stuckPodTracker) forcedReplacement(podKey string) (forcedReplacement, bool) {
+	t.Lock()
+	defer t.Unlock()
+	r, ok := t.forcedReplacements[podKey]
+	return r, ok
+}
+
+func (t *stuckPodTracker) removeForcedReplacement(podKey string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.forcedReplacements, podKey)
+}
+
+// replaceStuckPod finds the first pod in a set which has been stuck on a node
+// that is gone or not ready for longer than the pod replacement grace period,
+// and force deletes it so the statefulset recreates it on a healthy node. The
+// pod's placement instance is remembered so replaceForcedPods can replace it
+// as soon as the pod is recreated. If claim deletion is enabled and the
+// recreated pod will have a new identity, the pod's volume claims are deleted
+// too since they may be bound to the dead node. Returns true if a pod was
+// deleted.
+func (c *Controller) replaceStuckPod(cluster *myspec.M3DBCluster, set *appsv1.StatefulSet) (bool, error) {
+	if c.podReplacementGracePeriod <= 0 {
+		return false, nil
+	}
+
+	pods, err := c.podLister.Pods(set.Namespace).List(klabels.SelectorFromSet(set.Labels))
+	if err != nil {
+		return false, fmt.Errorf("error listing pods for set %s: %v", set.Name, err)
+	}
+
+	if len(pods) == 0 {
+		return false, nil
+	}
+
+	sortedPods, err := sortPods(pods)
+	if err != nil {
+		return false, fmt.Errorf("cannot sort pods: %v", err)
+	}
+
+	for _, p := range sortedPods {
+		pod := p.pod
+
+		stuckSince, stuck, err := c.podStuckSince(pod)
+		if err != nil {
+			return false, err
+		}
+		if !stuck {
+			continue
+		}
+
+		if remaining := c.podReplacementGracePeriod - c.clock.Since(stuckSince); remaining > 0 {
+			c.logger.Info("pod is on an unavailable node, waiting for grace period",
+				zap.String("pod", pod.Name),
+				zap.String("node", pod.Spec.NodeName),
+				zap.Duration("remaining", remaining))
+
+			// We won't necessarily get another event when the grace period
+			// expires, so check back then.
+			if key, err := cache.MetaNamespaceKeyFunc(cluster); err == nil {
+				c.clusterWorkQueue.AddAfter(key, remaining)
+			}
+			return false, nil
+		}
+
+		reason := fmt.Sprintf("pod %s stuck on unavailable node %s since %s, replacing it",
+			pod.Name, pod.Spec.NodeName, stuckSince.UTC().Format(time.RFC3339))
+		c.logger.Warn(reason)
+		c.recorder.WarningEvent(cluster, eventer.ReasonDeleting, reason)
+
+		for _, claim := range set.Spec.VolumeClaimTemplates {
+			if !c.podReplacementDeleteClaims || !identityChangesOnReschedule(cluster) {
+				// Either the pod keeps its identity and will reuse its data, or
+				// the user hasn't opted in to losing it.
+				break
+			}
+
+			claimName := claim.Name + "-" + pod.Name
+			err := c.kubeClient.CoreV1().PersistentVolumeClaims(pod.Namespace).Delete(claimName, &metav1.DeleteOptions{})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return false, fmt.Errorf("error deleting claim %s: %v", claimName, err)
+			}
+		}
+
+		if pl := c.activePlacement(cluster); pl != nil {
+			if inst, ok := instanceForPod(pl, pod.Name); ok {
+				podKey, err := cache.MetaNamespaceKeyFunc(pod)
+				if err != nil {
+					return false, err
+				}
+				c.stuckPods.addForcedReplacement(podKey, forcedReplacement{
+					instanceID: inst.ID(),
+					podUID:     pod.UID,
+				})
+			}
+		}
+
+		err = c.kubeClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{
+			GracePeriodSeconds: pointer.Int64Ptr(0),
+		})
+		if err != nil && !kerrors.IsNotFound(err) {
+			c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete, "failed to delete pod %s: %v", pod.Name, err)
+			return false, fmt.Errorf("error deleting pod %s: %v", pod.Name, err)
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// replaceForcedPods replaces the placement instance of the first pod deleted
+// by replaceStuckPod that has since been recreated and scheduled, without
+// waiting for the new pod to be ready: a pod whose identity changed can't
+// bootstrap until it's in the placement. Returns true if an instance was
+// replaced.
+func (c *Controller) replaceForcedPods(cluster *myspec.M3DBCluster, sets []*appsv1.StatefulSet) (bool, error) {
+	var pl placement.Placement
+
+	for _, set := range sets {
+		pods, err := c.podLister.Pods(set.Namespace).List(klabels.SelectorFromSet(set.Labels))
+		if err != nil {
+			return false, fmt.Errorf("error listing pods for set %s: %v", set.Name, err)
+		}
+
+		for _, pod := range pods {
+			podKey, err := cache.MetaNamespaceKeyFunc(pod)
+			if err != nil {
+				return false, err
+			}
+
+			r, ok := c.stuckPods.forcedReplacement(podKey)
+			if !ok || pod.UID == r.podUID || pod.Spec.NodeName == "" {
+				continue
+			}
+
+			if pl == nil {
+				if pl = c.activePlacement(cluster); pl == nil {
+					return false, nil
+				}
+			}
+
+			if _, ok := pl.Instance(r.instanceID); !ok {
+				// Already replaced by the regular replacement path.
+				c.stuckPods.removeForcedReplacement(podKey)
+				continue
+			}
+
+			id, err := c.podIDProvider.Identity(pod, cluster)
+			if err != nil {
+				return false, err
+			}
+			idStr, err := podidentity.IdentityJSON(id)
+			if err != nil {
+				return false, err
+			}
+			if idStr == r.instanceID {
+				// The pod kept its identity, nothing to replace.
+				c.stuckPods.removeForcedReplacement(podKey)
+				continue
+			}
+
+			if err := c.replacePodInPlacement(cluster, pl, r.instanceID, pod); err != nil {
+				c.recorder.WarningEvent(cluster, eventer.ReasonFailedToUpdate,
+					"failed to replace instance of force deleted pod %s: %v", pod.Name, err)
+				return false, err
+			}
+
+			c.stuckPods.removeForcedReplacement(podKey)
+			c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulUpdate,
+				"replaced instance of force deleted pod %s", pod.Name)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// instanceForPod returns the placement instance whose hostname belongs to the
+// named pod.
+func instanceForPod(pl placement.Placement, podName string) (placement.Instance, bool) {
+	for _, inst := range pl.Instances() {
+		if strings.EqualFold(strings.Split(inst.Hostname(), ".")[0], podName) {
+			return inst, true
+		}
+	}
+	return nil, false
+}
+
+// podStuckSince returns whether a pod that isn't ready is scheduled to a node
+// that is gone or not ready, and the time since which that has been the case.
+// For a node that is gone that's when the controller first found it missing.
+func (c *Controller) podStuckSince(pod *corev1.Pod) (time.Time, bool, error) {
+	if isPodReady(pod) || pod.Spec.NodeName == "" {
+		return time.Time{}, false, nil
+	}
+
+	node, err := c.nodeLister.Get(pod.Spec.NodeName)
+	if kerrors.IsNotFound(err) {
+		return c.stuckPods.nodeMissingSince(pod.Spec.NodeName, c.clock.Now()), true, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("error fetching node %s: %v", pod.Spec.NodeName, err)
+	}
+	c.stuckPods.nodeFound(node.Name)
+
+	for _, cond := range node.Status.Conditions {
+		if cond.Type != corev1.NodeReady || cond.Status == corev1.ConditionTrue {
+			continue
+		}
+
+		since := cond.LastTransitionTime.Time
+		if pod.CreationTimestamp.Time.After(since) {
+			since = pod.CreationTimestamp.Time
+		}
+		return since, true, nil
+	}
+
+	return time.Time{}, false, nil
+}
+
+// identityChangesOnReschedule returns true if a pod recreated on a different
+// node will have a different identity than the original pod.
+func identityChangesOnReschedule(cluster *myspec.M3DBCluster) bool {
+	cfg := cluster.Spec.PodIdentityConfig
+	if cfg == nil {
+		// Defaults to pod name and UID.
+		return true
 	}
 
-	c.logger.Info("removing pod from placement", zap.String("pod", removePod.Name))
-	return c.adminClient.placementClientForCluster(cluster).Remove(idStr)
+	return len(cfg.Sources) > 0
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// decommissionRemovedGroups handles the sets of isolation groups that were
+// removed from the cluster spec. Instances of a removed group are removed from
+// the placement one at a time, and once none of the group's instances remain
+// in the placement (i.e. their shards have been handed off) the group's set is
+// deleted. Returns true if any set of a removed group still exists, in which
+// case no other changes should be made to the cluster.
+func (c *Controller) decommissionRemovedGroups(
+	cluster *myspec.M3DBCluster,
+	pl placement.Placement,
+	sets []*appsv1.StatefulSet,
+) (bool, error) {
+	removed := removedGroupSets(cluster.Spec.IsolationGroups, sets)
+	if len(removed) == 0 {
+		return false, nil
+	}
+
+	if msg, blocked := removedGroupsBlockedReason(cluster); blocked {
+		c.logger.Warn(msg, zap.String("cluster", cluster.Name))
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete, msg)
+		return true, nil
+	}
+
+	set := removed[0]
+	group := set.Labels[labels.IsolationGroup]
+
+	insts := instancesInIsoGroup(pl, group)
+	if len(insts) > 0 {
+		sort.Sort(placement.ByIDAscending(insts))
+		inst := insts[0]
+
+		c.logger.Info("removing instance of removed isolation group from placement",
+			zap.String("instance", inst.ID()),
+			zap.String("isolationGroup", group))
+
+		if err := c.adminClient.placementClientForCluster(cluster).Remove(inst.ID()); err != nil {
+			c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete,
+				"failed to remove instance %s of isolation group %s from placement: %v", inst.ID(), group, err)
+			return false, fmt.Errorf("error removing instance %s from placement: %v", inst.ID(), err)
+		}
+
+		c.recorder.NormalEvent(cluster, eventer.ReasonDeleting,
+			"removing instance %s of isolation group %s from placement", inst.ID(), group)
+		return true, nil
+	}
+
+	// All of the group's instances have handed off their shards, safe to delete
+	// the set.
+	propagation := metav1.DeletePropagationBackground
+	err := c.kubeClient.AppsV1().StatefulSets(set.Namespace).Delete(set.Name, &metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !kerrors.IsNotFound(err) {
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete, "failed to delete statefulset %s: %v", set.Name, err)
+		return false, fmt.Errorf("error deleting statefulset %s: %v", set.Name, err)
+	}
+
+	c.logger.Info("deleted statefulset of removed isolation group",
+		zap.String("statefulSet", set.Name),
+		zap.String("isolationGroup", group))
+	c.recorder.NormalEvent(cluster, eventer.ReasonDeleting,
+		"deleted statefulset %s of removed isolation group %s", set.Name, group)
+	return true, nil
+}
+
+// removedGroupSets returns the sets whose isolation group isn't in groups,
+// sorted by name.
+func removedGroupSets(groups []myspec.IsolationGroup, sets []*appsv1.StatefulSet) []*appsv1.StatefulSet {
+	var removed []*appsv1.StatefulSet
+	for _, set := range sets {
+		if _, ok := myspec.IsolationGroups(groups).GetByName(set.Labels[labels.IsolationGroup]); !ok {
+			removed = append(removed, set)
+		}
+	}
+
+	sort.Slice(removed, func(i, j int) bool {
+		return removed[i].Name < removed[j].Name
+	})
+	return removed
+}
+
+// setForIsolationGroup returns the set of an isolation group, or nil if there
+// is none.
+func setForIsolationGroup(sets []*appsv1.StatefulSet, group string) *appsv1.StatefulSet {
+	for _, set := range sets {
+		if set.Labels[labels.IsolationGroup] == group {
+			return set
+		}
+	}
+	return nil
 }
 
 // findPodToRemove returns the pod name with the highest ordinal number in the
 // stateful set so that we remove from the placement the pod that will be
 // deleted when the set size is scaled down.
 func findPodToRemove(pods []*corev1.Pod) (*corev1.Pod, error) {
+	removePods, err := findPodsToRemove(pods, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return removePods[0], nil
+}
+
+// findPodsToRemove returns up to count pods with the highest ordinal numbers in
+// the list, highest first.
+func findPodsToRemove(pods []*corev1.Pod, count int) ([]*corev1.Pod, error) {
 	if len(pods) == 0 {
 		return nil, errors.New("cannot find removal candidate in empty list")
 	}
@@ -470,9 +1558,19 @@ func findPodToRemove(pods []*corev1.Pod) (*corev1.Pod, error) {
 		return nil, fmt.Errorf("cannot sort pods: %v", err)
 	}
 
-	lastPod := podIDs[len(podIDs)-1].pod
+	if count > len(podIDs) {
+		count = len(podIDs)
+	}
+	if count < 0 {
+		count = 0
+	}
+
+	removePods := make([]*corev1.Pod, 0, count)
+	for i := len(podIDs) - 1; i >= len(podIDs)-count; i-- {
+		removePods = append(removePods, podIDs[i].pod)
+	}
 
-	return lastPod, nil
+	return removePods, nil
 }
 
 func sortPods(pods []*corev1.Pod) ([]podID, error) {
@@ -32,6 +32,8 @@ import (
 	"github.com/m3db/m3db-operator/pkg/k8sops"
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
 	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
 
 	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
 	"github.com/m3db/m3/src/cluster/placement"
@@ -41,12 +43,17 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ktesting "k8s.io/client-go/testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/kubernetes/utils/pointer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -65,6 +72,8 @@ func (namespaceMatcher) String() string {
 
 func TestReconcileNamespaces(t *testing.T) {
 	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.NamespaceDeletionPolicy = myspec.NamespaceDeletionPolicyDelete
+	cluster.Spec.NamespaceDeletionGracePeriodSeconds = pointer.Int64Ptr(0)
 
 	deps := newTestDeps(t, &testOpts{
 		crdObjects: []runtime.Object{cluster},
@@ -87,13 +96,15 @@ func TestReconcileNamespaces(t *testing.T) {
 	nsMock.EXPECT().Delete("a").Return(nil)
 	nsMock.EXPECT().Create(namespaceMatcher{"metrics-10s:2d"}).Return(nil)
 
-	err := controller.reconcileNamespaces(cluster)
+	_, err := controller.reconcileNamespaces(cluster)
 	assert.NoError(t, err)
 }
 
 func TestCleanupNamespaces(t *testing.T) {
 	cluster := getFixture("cluster-simple.yaml", t)
 	cluster.Spec.Namespaces = []myspec.Namespace{}
+	cluster.Spec.NamespaceDeletionPolicy = myspec.NamespaceDeletionPolicyDelete
+	cluster.Spec.NamespaceDeletionGracePeriodSeconds = pointer.Int64Ptr(0)
 
 	deps := newTestDeps(t, &testOpts{
 		crdObjects: []runtime.Object{cluster},
@@ -108,24 +119,144 @@ func TestCleanupNamespaces(t *testing.T) {
 	}}
 
 	nsMock.EXPECT().Delete("foo").Return(nil)
-	err := controller.pruneNamespaces(cluster, registry)
+	_, err := controller.pruneNamespaces(cluster, registry)
 	assert.NoError(t, err)
 
 	nsMock.EXPECT().Delete("foo").Return(m3admin.ErrNotFound)
-	err = controller.pruneNamespaces(cluster, registry)
+	_, err = controller.pruneNamespaces(cluster, registry)
 	assert.NoError(t, err)
 
 	nsMock.EXPECT().Delete("foo").Return(errors.New("foo"))
-	err = controller.pruneNamespaces(cluster, registry)
+	_, err = controller.pruneNamespaces(cluster, registry)
 	assert.Error(t, err)
 
 	registry.Namespaces["baz"] = &dbns.NamespaceOptions{}
 	nsMock.EXPECT().Delete("foo").Return(nil)
 	nsMock.EXPECT().Delete("baz").Return(nil)
-	err = controller.pruneNamespaces(cluster, registry)
+	_, err = controller.pruneNamespaces(cluster, registry)
 	assert.NoError(t, err)
 }
 
+func TestPruneNamespacesRetained(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.Namespaces = []myspec.Namespace{}
+	cluster.Spec.NamespaceDeletionGracePeriodSeconds = pointer.Int64Ptr(0)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	nsMock := deps.namespaceClient
+
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	registry := &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+		"foo": &dbns.NamespaceOptions{},
+		"bar": &dbns.NamespaceOptions{},
+	}}
+
+	// Without opting in, namespaces are never deleted.
+	_, err := controller.pruneNamespaces(cluster, registry)
+	assert.NoError(t, err)
+
+	// Only the annotated namespace is deleted.
+	cluster.Annotations = map[string]string{"operator.m3db.io/delete-namespaces": "baz, foo"}
+	nsMock.EXPECT().Delete("foo").Return(nil)
+	_, err = controller.pruneNamespaces(cluster, registry)
+	assert.NoError(t, err)
+}
+
+func TestPruneNamespacesGracePeriod(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.Namespaces = []myspec.Namespace{}
+	cluster.Spec.NamespaceDeletionPolicy = myspec.NamespaceDeletionPolicyDelete
+
+	fakeClock := clock.NewFakeClock(time.Now())
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+		clock:      fakeClock,
+	})
+	nsMock := deps.namespaceClient
+
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	registry := &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+		"foo": &dbns.NamespaceOptions{},
+	}}
+
+	getCluster := func() *myspec.M3DBCluster {
+		cluster, err := deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return cluster
+	}
+
+	// The deletion is scheduled but the namespace isn't deleted.
+	_, err := controller.pruneNamespaces(cluster, registry)
+	assert.NoError(t, err)
+
+	cluster = getCluster()
+	expDeleteAfter := fakeClock.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	assert.Equal(t, []myspec.PendingNamespaceDeletion{
+		{Name: "foo", DeleteAfter: expDeleteAfter},
+	}, cluster.Status.PendingNamespaceDeletions)
+
+	fakeClock.Step(30 * time.Minute)
+	_, err = controller.pruneNamespaces(cluster, registry)
+	assert.NoError(t, err)
+	assert.Len(t, getCluster().Status.PendingNamespaceDeletions, 1)
+
+	// Deletion happens once the grace period has passed.
+	fakeClock.Step(31 * time.Minute)
+	nsMock.EXPECT().Delete("foo").Return(nil)
+	_, err = controller.pruneNamespaces(cluster, registry)
+	assert.NoError(t, err)
+
+	cluster = getCluster()
+	assert.Empty(t, cluster.Status.PendingNamespaceDeletions)
+}
+
+func TestPruneNamespacesCancelled(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.NamespaceDeletionPolicy = myspec.NamespaceDeletionPolicyDelete
+
+	fakeClock := clock.NewFakeClock(time.Now())
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+		clock:      fakeClock,
+	})
+
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	registry := &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+		"metrics-10s:2d": &dbns.NamespaceOptions{},
+	}}
+
+	specNamespaces := cluster.Spec.Namespaces
+	cluster.Spec.Namespaces = nil
+	updated, err := controller.pruneNamespaces(cluster, registry)
+	require.NoError(t, err)
+
+	// The returned cluster reflects the status update.
+	require.Len(t, updated.Status.PendingNamespaceDeletions, 1)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, cluster.Status.PendingNamespaceDeletions, 1)
+
+	// Restoring the namespace to the spec cancels the deletion, even after the
+	// grace period.
+	fakeClock.Step(2 * time.Hour)
+	cluster.Spec.Namespaces = specNamespaces
+	_, err = controller.pruneNamespaces(cluster, registry)
+	assert.NoError(t, err)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, cluster.Status.PendingNamespaceDeletions)
+}
+
 func TestCreateNamespaces(t *testing.T) {
 	cluster := getFixture("cluster-simple.yaml", t)
 	cluster.Spec.Namespaces = append(cluster.Spec.Namespaces, myspec.Namespace{
@@ -150,6 +281,82 @@ func TestCreateNamespaces(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// warningRecorder records the messages of warning events.
+type warningRecorder struct {
+	eventer.Poster
+	warnings []string
+}
+
+func (r *warningRecorder) WarningEvent(_ runtime.Object, _, message string, args ...interface{}) {
+	r.warnings = append(r.warnings, fmt.Sprintf(message, args...))
+}
+
+func TestUpdateNamespaces(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	nsMock := deps.namespaceClient
+
+	controller := deps.newController()
+	defer deps.cleanup()
+	recorder := &warningRecorder{Poster: eventer.NewNopPoster()}
+	controller.recorder = recorder
+
+	req, err := namespace.RequestFromSpec(cluster.Spec.Namespaces[0])
+	require.NoError(t, err)
+
+	registry := &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+		"metrics-10s:2d": req.Options,
+	}}
+
+	// No changes.
+	_, err = controller.updateNamespaces(cluster, registry)
+	assert.NoError(t, err)
+
+	// Retention period changes are applied.
+	current := *req.Options
+	retention := *req.Options.RetentionOptions
+	retention.RetentionPeriodNanos = (7 * 24 * time.Hour).Nanoseconds()
+	current.RetentionOptions = &retention
+	registry.Namespaces["metrics-10s:2d"] = &current
+
+	nsMock.EXPECT().Update(namespaceMatcher{"metrics-10s:2d"}).Return(nil)
+	_, err = controller.updateNamespaces(cluster, registry)
+	assert.NoError(t, err)
+
+	// Immutable changes block the update.
+	current.RepairEnabled = true
+	retention.BlockSizeNanos = (4 * time.Hour).Nanoseconds()
+
+	cluster, err = controller.updateNamespaces(cluster, registry)
+	assert.NoError(t, err)
+
+	const blockedMsg = "cannot update namespace metrics-10s:2d: repairEnabled, retentionOptions.blockSize cannot be changed"
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionNamespaceUpdateBlocked)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, blockedMsg, cond.Message)
+	assert.Equal(t, []string{blockedMsg}, recorder.warnings)
+
+	// No further warnings while the blocked update is unchanged.
+	cluster, err = controller.updateNamespaces(cluster, registry)
+	assert.NoError(t, err)
+	assert.Len(t, recorder.warnings, 1)
+
+	// Condition is cleared once the spec matches again.
+	registry.Namespaces["metrics-10s:2d"] = req.Options
+	_, err = controller.updateNamespaces(cluster, registry)
+	assert.NoError(t, err)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	cond, ok = cluster.Status.GetCondition(myspec.ClusterConditionNamespaceUpdateBlocked)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+}
+
 func TestNamespacesToCreate(t *testing.T) {
 	tests := []struct {
 		registry   *dbns.Registry
@@ -288,6 +495,49 @@ func TestSetStatus(t *testing.T) {
 	assert.Equal(t, c.Status, corev1.ConditionFalse)
 }
 
+func TestUpdateStatusConflict(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	// Simulate a concurrent spec edit that the controller's copy of the cluster
+	// hasn't seen, and fail the first status write with a conflict.
+	edited := cluster.DeepCopy()
+	edited.Spec.Image = "foo/m3dbnode:edited"
+	_, err := deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(edited)
+	require.NoError(t, err)
+
+	conflicts := 0
+	deps.crdClient.PrependReactor("update", "m3dbclusters", func(action ktesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "status" || conflicts > 0 {
+			return false, nil, nil
+		}
+		conflicts++
+		return true, nil, kerrors.NewConflict(schema.GroupResource{Resource: "m3dbclusters"}, cluster.Name, errors.New("conflict"))
+	})
+
+	updated, err := controller.updateStatus(cluster, func(status *myspec.M3DBStatus) {
+		status.Message = "foo"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, conflicts)
+	assert.Equal(t, "foo", updated.Status.Message)
+	assert.Equal(t, "foo/m3dbnode:edited", updated.Spec.Image)
+
+	var statusUpdates int
+	for _, action := range deps.crdClient.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+			statusUpdates++
+		}
+	}
+	// One conflicting write and one successful one.
+	assert.Equal(t, 2, statusUpdates)
+}
+
 func TestReconcileBootstrappingStatus(t *testing.T) {
 	cluster := getFixture("cluster-simple.yaml", t)
 
@@ -465,6 +715,68 @@ func TestExpandPlacementForSet(t *testing.T) {
 	assert.True(t, cluster.Status.HasPodBootstrapping())
 }
 
+func TestExpandPlacementBatch(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Spec.ScalingPolicy.BatchExpansion = true
+
+	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	require.NoError(t, err)
+	set.Namespace = cluster.Namespace
+	set.Status.ReadyReplicas = 3
+
+	pods := podsForClusterSet(cluster, set, 5)
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: append(objectsFromPods(pods...), set),
+		crdObjects:  []runtime.Object{cluster},
+	})
+	placementMock := deps.placementClient
+	idProvider := deps.idProvider
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	for _, pod := range pods {
+		pod := pod
+		idProvider.EXPECT().Identity(newPodNameMatcher(pod.Name), gomock.Any()).Return(identityForPod(pod), nil).AnyTimes()
+	}
+
+	pl := placementFromPods(t, cluster, pods[0:3], idProvider)
+	cluster.Spec.IsolationGroups[0].NumInstances = 5
+
+	// The set is first scaled straight to its desired size.
+	expanded, err := controller.expandPlacementBatch(cluster, []*appsv1.StatefulSet{set}, cluster.Spec.IsolationGroups, pl)
+	require.NoError(t, err)
+	assert.True(t, expanded)
+
+	set, err = deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(set.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), *set.Spec.Replicas)
+
+	// Once scaled all new pods are added at once.
+	set.Status.ReadyReplicas = 5
+
+	var expInsts []placementpb.Instance
+	for _, pod := range pods[3:] {
+		inst, err := k8sops.PlacementInstanceFromPod(cluster, pod, idProvider)
+		require.NoError(t, err)
+		expInsts = append(expInsts, *inst)
+	}
+	placementMock.EXPECT().AddMany(expInsts)
+
+	expanded, err = controller.expandPlacementBatch(cluster, []*appsv1.StatefulSet{set}, cluster.Spec.IsolationGroups, pl)
+	require.NoError(t, err)
+	assert.True(t, expanded)
+
+	cluster, err = deps.crdClient.Operator().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, cluster.Status.HasPodBootstrapping())
+
+	// Nothing to do once all pods are in the placement.
+	pl = placementFromPods(t, cluster, pods, idProvider)
+	expanded, err = controller.expandPlacementBatch(cluster, []*appsv1.StatefulSet{set}, cluster.Spec.IsolationGroups, pl)
+	require.NoError(t, err)
+	assert.False(t, expanded)
+}
+
 func TestExpandPlacementForSet_Nop(t *testing.T) {
 	deps := newTestDeps(t, &testOpts{})
 	controller := deps.newController()
@@ -507,7 +819,7 @@ func TestExpandPlacementForSet_Err(t *testing.T) {
 	}
 
 	pl := placementFromPods(t, cluster, pods, idProvider)
-	const expErr = "cannot expand set 'cluster-zones-rep0', not yet ready"
+	const expErr = "cannot expand set 'cluster-zones-us-fake1-a', not yet ready"
 	err = controller.expandPlacementForSet(cluster, set, group, pl)
 	assert.Equal(t, expErr, err.Error())
 }
@@ -527,12 +839,120 @@ func TestShrinkPlacementForSet(t *testing.T) {
 	controller := deps.newController()
 	defer deps.cleanup()
 
-	deps.idProvider.EXPECT().Identity(newPodNameMatcher(pods[2].Name), cluster).Return(identityForPod(pods[2]), nil)
-	placementMock.EXPECT().Remove(`{"name":"cluster-zones-rep0-2","uid":"2"}`)
-	err = controller.shrinkPlacementForSet(cluster, set)
+	for _, pod := range pods {
+		pod := pod
+		deps.idProvider.EXPECT().Identity(newPodNameMatcher(pod.Name), cluster).Return(identityForPod(pod), nil).AnyTimes()
+	}
+	pl := placementFromPods(t, cluster, pods, deps.idProvider)
+
+	placementMock.EXPECT().Remove(`{"name":"cluster-zones-us-fake1-a-2","uid":"2"}`)
+	err = controller.shrinkPlacementForSet(cluster, set, pl, 2, 1)
 	assert.NoError(t, err)
 }
 
+func TestShrinkPlacementForSetMany(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 5)
+	require.NoError(t, err)
+
+	pods := podsForClusterSet(cluster, set, 5)
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: objectsFromPods(pods...),
+	})
+	placementMock := deps.placementClient
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	for _, pod := range pods {
+		pod := pod
+		deps.idProvider.EXPECT().Identity(newPodNameMatcher(pod.Name), cluster).Return(identityForPod(pod), nil).AnyTimes()
+	}
+
+	// The last pod was removed in an earlier step, so the next 3 are removed in
+	// a single placement change.
+	pl := placementFromPods(t, cluster, pods[:4], deps.idProvider)
+	placementMock.EXPECT().RemoveMany([]string{
+		`{"name":"cluster-zones-us-fake1-a-3","uid":"3"}`,
+		`{"name":"cluster-zones-us-fake1-a-2","uid":"2"}`,
+		`{"name":"cluster-zones-us-fake1-a-1","uid":"1"}`,
+	})
+	err = controller.shrinkPlacementForSet(cluster, set, pl, 1, 3)
+	assert.NoError(t, err)
+
+	// Coordinators without the remove endpoint remove a single instance.
+	gomock.InOrder(
+		placementMock.EXPECT().RemoveMany(gomock.Any()).Return(m3admin.ErrNotFound),
+		placementMock.EXPECT().Remove(`{"name":"cluster-zones-us-fake1-a-3","uid":"3"}`),
+	)
+	err = controller.shrinkPlacementForSet(cluster, set, pl, 1, 3)
+	assert.NoError(t, err)
+
+	// Pods below the desired size are never removed.
+	pl = placementFromPods(t, cluster, pods[:2], deps.idProvider)
+	err = controller.shrinkPlacementForSet(cluster, set, pl, 2, 3)
+	assert.Error(t, err)
+}
+
+func TestMaxInstancesRemovedPerStep(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	assert.Equal(t, int32(1), maxInstancesRemovedPerStep(cluster))
+	assert.Equal(t, int32(1), instancesToRemove(cluster, 1, 3))
+
+	cluster.Spec.ScalingPolicy.MaxInstancesRemovedPerStep = -2
+	assert.Equal(t, int32(1), maxInstancesRemovedPerStep(cluster))
+
+	cluster.Spec.ScalingPolicy.MaxInstancesRemovedPerStep = 4
+	assert.Equal(t, int32(4), maxInstancesRemovedPerStep(cluster))
+	assert.Equal(t, int32(2), instancesToRemove(cluster, 1, 3))
+	assert.Equal(t, int32(4), instancesToRemove(cluster, 1, 7))
+}
+
+func TestShrinkWouldBreakReplication(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	assert.False(t, shrinkWouldBreakReplication(cluster, 1))
+
+	// Emptying one of 3 groups with RF 3 leaves too few groups.
+	cluster.Spec.IsolationGroups[0].NumInstances = 0
+	assert.True(t, shrinkWouldBreakReplication(cluster, 0))
+
+	cluster.Spec.ReplicationFactor = 2
+	assert.False(t, shrinkWouldBreakReplication(cluster, 0))
+}
+
+func TestNextScaleStep(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	for _, test := range []struct {
+		name                          string
+		desired, current, inPlacement int32
+		maxRemoved                    int32
+		expStep                       scaleStep
+		expCount                      int32
+	}{
+		{name: "at size", desired: 3, current: 3, inPlacement: 3, expStep: scaleStepNone, expCount: 3},
+		{name: "grow set", desired: 3, current: 1, inPlacement: 1, expStep: scaleStepResizeSet, expCount: 2},
+		{name: "add instance", desired: 3, current: 3, inPlacement: 2, expStep: scaleStepAddInstance, expCount: 3},
+		{name: "remove instance", desired: 1, current: 3, inPlacement: 3, expStep: scaleStepRemoveInstance, expCount: 3},
+		{name: "shrink set", desired: 1, current: 3, inPlacement: 1, expStep: scaleStepResizeSet, expCount: 2},
+		{name: "shrink blocked", desired: 0, current: 3, inPlacement: 3, expStep: scaleStepRemoveBlocked, expCount: 3},
+		{name: "shrink set many", desired: 1, current: 5, inPlacement: 1, maxRemoved: 3, expStep: scaleStepResizeSet, expCount: 2},
+		{name: "shrink set to desired", desired: 1, current: 3, inPlacement: 1, maxRemoved: 3, expStep: scaleStepResizeSet, expCount: 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := cluster.DeepCopy()
+			cluster.Spec.IsolationGroups[0].NumInstances = test.desired
+			cluster.Spec.ScalingPolicy.MaxInstancesRemovedPerStep = test.maxRemoved
+
+			step, count := nextScaleStep(cluster, test.desired, test.current, test.inPlacement)
+			assert.Equal(t, test.expStep, step)
+			assert.Equal(t, test.expCount, count)
+		})
+	}
+}
+
 func podWithName(name string) *corev1.Pod {
 	return &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -683,11 +1103,11 @@ func TestReplacePodInPlacement(t *testing.T) {
 	require.NotNil(t, testNewPod)
 
 	expInstance := placementpb.Instance{
-		Id:             "{\"name\":\"cluster-zones-rep0-0\",\"uid\":\"ABC\"}",
+		Id:             "{\"name\":\"cluster-zones-us-fake1-a-0\",\"uid\":\"ABC\"}",
 		IsolationGroup: "zone-a",
 		Zone:           "embedded",
-		Endpoint:       "cluster-zones-rep0-0.m3dbnode-cluster-zones:9000",
-		Hostname:       "cluster-zones-rep0-0.m3dbnode-cluster-zones",
+		Endpoint:       "cluster-zones-us-fake1-a-0.m3dbnode-cluster-zones:9000",
+		Hostname:       "cluster-zones-us-fake1-a-0.m3dbnode-cluster-zones",
 		Port:           9000,
 		Weight:         100,
 	}
@@ -806,3 +1226,606 @@ func TestFindPodToRemove(t *testing.T) {
 		}
 	}
 }
+
+func TestFindPodsToRemove(t *testing.T) {
+	_, err := findPodsToRemove(nil, 2)
+	assert.Error(t, err)
+
+	pods := []*corev1.Pod{
+		podWithName("foo-2"),
+		podWithName("foo-10"),
+		podWithName("foo-1"),
+	}
+
+	removePods, err := findPodsToRemove(pods, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []*corev1.Pod{podWithName("foo-10"), podWithName("foo-2")}, removePods)
+
+	removePods, err = findPodsToRemove(pods, 5)
+	require.NoError(t, err)
+	assert.Len(t, removePods, 3)
+}
+
+func TestUpdateStatefulSetTemplates(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	cm, err := k8sops.GenerateDefaultConfigMap(cluster)
+	require.NoError(t, err)
+	cm.Namespace = cluster.Namespace
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{cm},
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	set, err := controller.generateStatefulSet(cluster, "us-fake1-a", 3)
+	require.NoError(t, err)
+	set.Namespace = cluster.Namespace
+	_, err = deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Create(set)
+	require.NoError(t, err)
+
+	updated, err := controller.updateStatefulSetTemplates(cluster, []*appsv1.StatefulSet{set.DeepCopy()})
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	cluster.Spec.Image = "foo/m3dbnode:new"
+	updated, err = controller.updateStatefulSetTemplates(cluster, []*appsv1.StatefulSet{set.DeepCopy()})
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	newSet, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(set.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "foo/m3dbnode:new", newSet.Spec.Template.Spec.Containers[0].Image)
+	assert.NotEqual(t, set.Annotations["operator.m3db.io/pod-template-hash"],
+		newSet.Annotations["operator.m3db.io/pod-template-hash"])
+	assert.Equal(t, int32(3), *newSet.Spec.Replicas)
+}
+
+func TestUpdateStatefulSetTemplatesConfigChange(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	cm, err := k8sops.GenerateDefaultConfigMap(cluster)
+	require.NoError(t, err)
+	cm.Namespace = cluster.Namespace
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{cm},
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	set, err := controller.generateStatefulSet(cluster, "us-fake1-a", 3)
+	require.NoError(t, err)
+	set.Namespace = cluster.Namespace
+	_, err = deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Create(set)
+	require.NoError(t, err)
+
+	cm = cm.DeepCopy()
+	cm.Data["m3.yml"] += "\n# changed"
+	_, err = deps.kubeClient.CoreV1().ConfigMaps(cluster.Namespace).Update(cm)
+	require.NoError(t, err)
+
+	// Wait for the configmap change to be reflected in the lister.
+	err = wait.Poll(time.Millisecond, 5*time.Second, func() (bool, error) {
+		cached, err := deps.configMapLister.ConfigMaps(cluster.Namespace).Get(cm.Name)
+		if err != nil {
+			return false, err
+		}
+		return cached.Data["m3.yml"] == cm.Data["m3.yml"], nil
+	})
+	require.NoError(t, err)
+
+	updated, err := controller.updateStatefulSetTemplates(cluster, []*appsv1.StatefulSet{set.DeepCopy()})
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	newSet, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(set.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, k8sops.ConfigMapHash(cm), newSet.Spec.Template.Annotations["operator.m3db.io/config-hash"])
+	assert.NotEqual(t, set.Spec.Template.Annotations["operator.m3db.io/config-hash"],
+		newSet.Spec.Template.Annotations["operator.m3db.io/config-hash"])
+}
+
+func TestUpdateStatefulSetTemplatesLegacyName(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	cm, err := k8sops.GenerateDefaultConfigMap(cluster)
+	require.NoError(t, err)
+	cm.Namespace = cluster.Namespace
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{cm},
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	// Sets named by group index must not be considered outdated.
+	set, err := controller.generateStatefulSet(cluster, "us-fake1-a", 3,
+		k8sops.WithStatefulSetName("cluster-zones-rep0"))
+	require.NoError(t, err)
+	set.Namespace = cluster.Namespace
+	_, err = deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Create(set)
+	require.NoError(t, err)
+
+	updated, err := controller.updateStatefulSetTemplates(cluster, []*appsv1.StatefulSet{set.DeepCopy()})
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	cluster.Spec.Image = "foo/m3dbnode:new"
+	updated, err = controller.updateStatefulSetTemplates(cluster, []*appsv1.StatefulSet{set.DeepCopy()})
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	newSet, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get("cluster-zones-rep0", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "cluster-zones-rep0", newSet.Spec.Template.Spec.Containers[0].Name)
+}
+
+func TestRestartOutdatedPods(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	require.NoError(t, err)
+	set.Status.UpdateRevision = "rev-b"
+
+	pods := podsForClusterSet(cluster, set, 3)
+	pods[0].Labels[appsv1.ControllerRevisionHashLabelKey] = "rev-b"
+	pods[1].Labels[appsv1.ControllerRevisionHashLabelKey] = "rev-a"
+	pods[2].Labels[appsv1.ControllerRevisionHashLabelKey] = "rev-a"
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: objectsFromPods(pods...),
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	expectPodIdentities(deps)
+	pl := placementFromPods(t, cluster, pods, deps.idProvider)
+
+	restarted, err := controller.restartOutdatedPods(cluster, []*appsv1.StatefulSet{set}, pods, pl)
+	require.NoError(t, err)
+	assert.True(t, restarted)
+
+	// Only the lowest ordinal outdated pod should have been deleted.
+	_, err = deps.kubeClient.CoreV1().Pods(cluster.Namespace).Get(pods[1].Name, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	for _, i := range []int{0, 2} {
+		_, err = deps.kubeClient.CoreV1().Pods(cluster.Namespace).Get(pods[i].Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+	}
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, cluster.Status.HasPodBootstrapping())
+
+	// A set that hasn't observed its latest generation must be waited on.
+	set.Generation = 2
+	set.Status.ObservedGeneration = 1
+	restarted, err = controller.restartOutdatedPods(cluster, []*appsv1.StatefulSet{set}, pods, pl)
+	require.NoError(t, err)
+	assert.True(t, restarted)
+}
+
+// expectPodIdentities makes the mock identity provider return each pod's name
+// and UID as its identity, as the default PodUID source does.
+func expectPodIdentities(deps *testDeps) {
+	deps.idProvider.EXPECT().Identity(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(pod *corev1.Pod, _ *myspec.M3DBCluster) (*myspec.PodIdentity, error) {
+			return identityForPod(pod), nil
+		}).AnyTimes()
+}
+
+func TestRestartOutdatedPodsWaitsForReplacement(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	require.NoError(t, err)
+	set.Status.UpdateRevision = "rev-b"
+
+	pods := podsForClusterSet(cluster, set, 3)
+	for _, pod := range pods {
+		pod.Labels[appsv1.ControllerRevisionHashLabelKey] = "rev-a"
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: objectsFromPods(pods...),
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	expectPodIdentities(deps)
+	pl := placementFromPods(t, cluster, pods, deps.idProvider)
+
+	// The first pod was restarted and came back with a new UID, so its identity
+	// no longer matches its instance in the placement.
+	restartedPod := pods[0].DeepCopy()
+	restartedPod.UID = "restarted"
+	restartedPod.Labels[appsv1.ControllerRevisionHashLabelKey] = "rev-b"
+	require.NoError(t, deps.kubeClient.CoreV1().Pods(cluster.Namespace).Delete(pods[0].Name, &metav1.DeleteOptions{}))
+	_, err = deps.kubeClient.CoreV1().Pods(cluster.Namespace).Create(restartedPod)
+	require.NoError(t, err)
+	pods[0] = restartedPod
+
+	// No other pod may be restarted until the first one has been replaced.
+	restarted, err := controller.restartOutdatedPods(cluster, []*appsv1.StatefulSet{set}, pods, pl)
+	require.NoError(t, err)
+	assert.True(t, restarted)
+	for _, pod := range pods[1:] {
+		_, err = deps.kubeClient.CoreV1().Pods(cluster.Namespace).Get(pod.Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+	}
+
+	// Once the placement reflects the new identity the rollout continues.
+	pl = placementFromPods(t, cluster, pods, deps.idProvider)
+	require.NoError(t, wait.Poll(time.Millisecond, 5*time.Second, func() (bool, error) {
+		pod, err := deps.podLister.Pods(cluster.Namespace).Get(pods[0].Name)
+		if err != nil {
+			return false, nil
+		}
+		return pod.UID == "restarted", nil
+	}))
+
+	restarted, err = controller.restartOutdatedPods(cluster, []*appsv1.StatefulSet{set}, pods, pl)
+	require.NoError(t, err)
+	assert.True(t, restarted)
+	_, err = deps.kubeClient.CoreV1().Pods(cluster.Namespace).Get(pods[1].Name, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
+
+func TestReplaceStuckPod(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Spec.DataDirVolumeClaimTemplate = &corev1.PersistentVolumeClaim{}
+
+	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	require.NoError(t, err)
+
+	now := time.Now()
+	fakeClock := clock.NewFakeClock(now)
+
+	readyCond := corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue}
+	pods := podsForClusterSet(cluster, set, 3)
+	pods[0].Spec.NodeName = "node-a"
+	pods[0].Status.Conditions = []corev1.PodCondition{readyCond}
+	pods[1].Spec.NodeName = "node-b"
+	pods[1].Status.Phase = corev1.PodPending
+	pods[2].Spec.NodeName = "node-a"
+	pods[2].Status.Conditions = []corev1.PodCondition{readyCond}
+
+	nodes := []*corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-b"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{
+						Type:               corev1.NodeReady,
+						Status:             corev1.ConditionUnknown,
+						LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)),
+					},
+				},
+			},
+		},
+	}
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "m3db-data-" + pods[1].Name,
+			Namespace: cluster.Namespace,
+		},
+	}
+
+	objects := append(objectsFromPods(pods...), nodes[0], nodes[1], claim)
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: objects,
+		crdObjects:  []runtime.Object{cluster},
+		clock:       fakeClock,
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	// Disabled by default.
+	replaced, err := controller.replaceStuckPod(cluster, set)
+	require.NoError(t, err)
+	assert.False(t, replaced)
+
+	// Within the grace period.
+	controller.podReplacementGracePeriod = 15 * time.Minute
+	replaced, err = controller.replaceStuckPod(cluster, set)
+	require.NoError(t, err)
+	assert.False(t, replaced)
+
+	fakeClock.Step(10 * time.Minute)
+	controller.podReplacementDeleteClaims = true
+	replaced, err = controller.replaceStuckPod(cluster, set)
+	require.NoError(t, err)
+	assert.True(t, replaced)
+
+	_, err = deps.kubeClient.CoreV1().Pods(cluster.Namespace).Get(pods[1].Name, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = deps.kubeClient.CoreV1().PersistentVolumeClaims(cluster.Namespace).Get(claim.Name, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	for _, pod := range []*corev1.Pod{pods[0], pods[2]} {
+		_, err = deps.kubeClient.CoreV1().Pods(cluster.Namespace).Get(pod.Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+	}
+}
+
+func TestReplaceStuckPodMissingNode(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Spec.DataDirVolumeClaimTemplate = &corev1.PersistentVolumeClaim{}
+	cluster.Status.UpdateCondition(myspec.ClusterCondition{
+		Type:   myspec.ClusterConditionPlacementInitialized,
+		Status: corev1.ConditionTrue,
+	})
+
+	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	require.NoError(t, err)
+	set.Namespace = cluster.Namespace
+
+	fakeClock := clock.NewFakeClock(time.Now())
+
+	readyCond := corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue}
+	pods := podsForClusterSet(cluster, set, 3)
+	for _, pod := range pods {
+		pod.Spec.NodeName = "node-a"
+		pod.Status.Conditions = []corev1.PodCondition{readyCond}
+		// The pods are much older than the grace period.
+		pod.CreationTimestamp = metav1.NewTime(fakeClock.Now().Add(-24 * time.Hour))
+	}
+	pods[1].Spec.NodeName = "node-gone"
+	pods[1].Status.Conditions = nil
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "m3db-data-" + pods[1].Name,
+			Namespace: cluster.Namespace,
+		},
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: append(objectsFromPods(pods...), node, claim),
+		crdObjects:  []runtime.Object{cluster},
+		clock:       fakeClock,
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	expectPodIdentities(deps)
+	pl := placementFromPods(t, cluster, pods, deps.idProvider)
+	leavingID, err := podidentity.IdentityJSON(identityForPod(pods[1]))
+	require.NoError(t, err)
+	deps.placementClient.EXPECT().Get().Return(pl, nil).AnyTimes()
+
+	// The grace period runs from when the node was first found missing, not
+	// from when the pod was created.
+	controller.podReplacementGracePeriod = 15 * time.Minute
+	replaced, err := controller.replaceStuckPod(cluster, set)
+	require.NoError(t, err)
+	assert.False(t, replaced)
+
+	fakeClock.Step(10 * time.Minute)
+	replaced, err = controller.replaceStuckPod(cluster, set)
+	require.NoError(t, err)
+	assert.False(t, replaced)
+
+	fakeClock.Step(5 * time.Minute)
+	replaced, err = controller.replaceStuckPod(cluster, set)
+	require.NoError(t, err)
+	assert.True(t, replaced)
+
+	_, err = deps.kubeClient.CoreV1().Pods(cluster.Namespace).Get(pods[1].Name, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	// Claims are kept unless their deletion is enabled.
+	_, err = deps.kubeClient.CoreV1().PersistentVolumeClaims(cluster.Namespace).Get(claim.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// Nothing to replace until the pod is recreated.
+	replaced, err = controller.replaceForcedPods(cluster, []*appsv1.StatefulSet{set})
+	require.NoError(t, err)
+	assert.False(t, replaced)
+
+	recreatedPod := pods[1].DeepCopy()
+	recreatedPod.UID = "recreated"
+	recreatedPod.Spec.NodeName = "node-a"
+	_, err = deps.kubeClient.CoreV1().Pods(cluster.Namespace).Create(recreatedPod)
+	require.NoError(t, err)
+	require.NoError(t, wait.Poll(time.Millisecond, 5*time.Second, func() (bool, error) {
+		pod, err := deps.podLister.Pods(cluster.Namespace).Get(recreatedPod.Name)
+		if err != nil {
+			return false, nil
+		}
+		return pod.UID == recreatedPod.UID, nil
+	}))
+
+	// The recreated pod is replaced in the placement even though it isn't
+	// ready, and only once.
+	deps.placementClient.EXPECT().Replace(leavingID, gomock.Any()).Return(nil)
+	replaced, err = controller.replaceForcedPods(cluster, []*appsv1.StatefulSet{set})
+	require.NoError(t, err)
+	assert.True(t, replaced)
+
+	replaced, err = controller.replaceForcedPods(cluster, []*appsv1.StatefulSet{set})
+	require.NoError(t, err)
+	assert.False(t, replaced)
+}
+
+func TestIdentityChangesOnReschedule(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+
+	cluster.Spec.PodIdentityConfig = nil
+	assert.True(t, identityChangesOnReschedule(cluster))
+
+	cluster.Spec.PodIdentityConfig = &myspec.PodIdentityConfig{}
+	assert.False(t, identityChangesOnReschedule(cluster))
+
+	cluster.Spec.PodIdentityConfig.Sources = []myspec.PodIdentitySource{myspec.PodIdentitySourceNodeName}
+	assert.True(t, identityChangesOnReschedule(cluster))
+}
+
+func TestPodStuckSince(t *testing.T) {
+	now := time.Now()
+	fakeClock := clock.NewFakeClock(now)
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{node},
+		clock:       fakeClock,
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "pod-0",
+			CreationTimestamp: metav1.NewTime(now.Add(-time.Hour)),
+		},
+	}
+
+	// Unscheduled pods aren't stuck on a node.
+	_, stuck, err := controller.podStuckSince(pod)
+	require.NoError(t, err)
+	assert.False(t, stuck)
+
+	pod.Spec.NodeName = "node-a"
+	_, stuck, err = controller.podStuckSince(pod)
+	require.NoError(t, err)
+	assert.False(t, stuck)
+
+	// A missing node counts from when it was first found missing.
+	pod.Spec.NodeName = "node-gone"
+	since, stuck, err := controller.podStuckSince(pod)
+	require.NoError(t, err)
+	assert.True(t, stuck)
+	assert.Equal(t, now, since)
+
+	fakeClock.Step(time.Minute)
+	since, stuck, err = controller.podStuckSince(pod)
+	require.NoError(t, err)
+	assert.True(t, stuck)
+	assert.Equal(t, now, since)
+}
+
+func TestDecommissionRemovedGroups(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	var sets []*appsv1.StatefulSet
+	for _, group := range cluster.Spec.IsolationGroups {
+		set, err := k8sops.GenerateStatefulSet(cluster, group.Name, group.NumInstances)
+		require.NoError(t, err)
+		set.Namespace = cluster.Namespace
+		sets = append(sets, set)
+	}
+
+	// Remove the first group.
+	cluster.Spec.IsolationGroups = cluster.Spec.IsolationGroups[1:]
+
+	newInst := func(id, group string) placement.Instance {
+		return placement.NewInstance().
+			SetID(id).
+			SetIsolationGroup(group).
+			SetShards(shard.NewShards([]shard.Shard{
+				shard.NewShard(0).SetState(shard.Available),
+			}))
+	}
+
+	pl := placement.NewPlacement().SetInstances([]placement.Instance{
+		newInst("a-2", "us-fake1-a"),
+		newInst("a-1", "us-fake1-a"),
+		newInst("b-1", "us-fake1-b"),
+		newInst("c-1", "us-fake1-c"),
+	})
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{sets[0], sets[1], sets[2]},
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController()
+	defer deps.cleanup()
+
+	// Refuse to remove the group if fewer groups than the RF would remain.
+	decommissioning, err := controller.decommissionRemovedGroups(cluster, pl, sets)
+	require.NoError(t, err)
+	assert.True(t, decommissioning)
+
+	cluster.Spec.ReplicationFactor = 2
+
+	deps.placementClient.EXPECT().Remove("a-1").Return(nil)
+	decommissioning, err = controller.decommissionRemovedGroups(cluster, pl, sets)
+	require.NoError(t, err)
+	assert.True(t, decommissioning)
+
+	_, err = deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(sets[0].Name, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// Once the group's instances have left the placement the set is deleted.
+	pl = placement.NewPlacement().SetInstances([]placement.Instance{
+		newInst("b-1", "us-fake1-b"),
+		newInst("c-1", "us-fake1-c"),
+	})
+	decommissioning, err = controller.decommissionRemovedGroups(cluster, pl, sets)
+	require.NoError(t, err)
+	assert.True(t, decommissioning)
+
+	_, err = deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(sets[0].Name, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	decommissioning, err = controller.decommissionRemovedGroups(cluster, pl, sets[1:])
+	require.NoError(t, err)
+	assert.False(t, decommissioning)
+}
+
+func TestRemovedGroupSets(t *testing.T) {
+	newSet := func(name, group string) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"operator.m3db.io/isolation-group": group},
+			},
+		}
+	}
+
+	sets := []*appsv1.StatefulSet{
+		newSet("set-c", "c"),
+		newSet("set-b", "b"),
+		newSet("set-a", "a"),
+	}
+	groups := []myspec.IsolationGroup{{Name: "b"}, {Name: "d"}}
+
+	removed := removedGroupSets(groups, sets)
+	require.Len(t, removed, 2)
+	assert.Equal(t, "set-a", removed[0].Name)
+	assert.Equal(t, "set-c", removed[1].Name)
+
+	assert.Equal(t, sets[1], setForIsolationGroup(sets, "b"))
+	assert.Nil(t, setForIsolationGroup(sets, "d"))
+}
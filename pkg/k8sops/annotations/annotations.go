@@ -0,0 +1,45 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package annotations defines constants and helpers for annotations used
+// throughout the m3db operator.
+package annotations
+
+const (
+	// PodTemplateHash is the annotation on a StatefulSet recording a hash of the
+	// pod template the operator generated for it. A mismatch with the hash of a
+	// freshly generated template means the set's template has drifted from the
+	// cluster spec.
+	PodTemplateHash = "operator.m3db.io/pod-template-hash"
+
+	// ConfigHash is the annotation on a pod template recording a hash of the m3
+	// configuration mounted by the pods. Changing the configuration changes the
+	// template, which causes the pods to be restarted.
+	ConfigHash = "operator.m3db.io/config-hash"
+
+	// DeleteNamespaces is the annotation on an M3DBCluster listing, separated by
+	// commas, the namespaces that may be deleted once removed from the spec
+	// regardless of the cluster's namespace deletion policy.
+	DeleteNamespaces = "operator.m3db.io/delete-namespaces"
+
+	// DryRun is the annotation on an M3DBCluster that, when set to "true", makes
+	// the operator only plan the changes it would make to the cluster.
+	DryRun = "operator.m3db.io/dry-run"
+)
@@ -22,6 +22,9 @@ package k8sops
 
 import (
 	"fmt"
+	"strings"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
@@ -29,13 +32,59 @@ import (
 const (
 	headlessServicePrefix    = "m3dbnode-"
 	coordinatorServicePrefix = "m3coordinator-"
+
+	// MaxStatefulSetNameLength is the longest a StatefulSet name can be, as the
+	// pods of a set are labeled with the set's name and a 10 character revision
+	// hash, and label values are limited to 63 characters.
+	MaxStatefulSetNameLength = 52
 )
 
 // StatefulSetName provides a formatted string to use for naming StatefulSets
+// by the index of their isolation group. Sets are no longer created with these
+// names, but sets of existing clusters may still have them.
 func StatefulSetName(clusterName string, stsID int) string {
 	return fmt.Sprintf("%s-rep%d", clusterName, stsID)
 }
 
+// StatefulSetNameForGroup returns the name of the StatefulSet for an isolation
+// group of a cluster.
+func StatefulSetNameForGroup(clusterName, isolationGroup string) string {
+	group := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, isolationGroup)
+
+	return clusterName + "-" + strings.Trim(group, "-")
+}
+
+// ValidateStatefulSetNameForGroup returns an error if the StatefulSet name for
+// an isolation group of a cluster is too long, or is the same as the name for
+// another of the cluster's isolation groups (e.g. "us_east_1a" and
+// "us-east-1a").
+func ValidateStatefulSetNameForGroup(cluster *myspec.M3DBCluster, isolationGroup string) error {
+	name := StatefulSetNameForGroup(cluster.Name, isolationGroup)
+	if len(name) > MaxStatefulSetNameLength {
+		return fmt.Errorf("statefulset name '%s' is longer than %d characters", name, MaxStatefulSetNameLength)
+	}
+
+	for _, group := range cluster.Spec.IsolationGroups {
+		if group.Name == isolationGroup {
+			continue
+		}
+		if StatefulSetNameForGroup(cluster.Name, group.Name) == name {
+			return fmt.Errorf("statefulset name '%s' is the same as that of isolation group '%s'", name, group.Name)
+		}
+	}
+
+	return nil
+}
+
 // HeadlessServiceName returns a name for the cluster's headless service.
 func HeadlessServiceName(clusterName string) string {
 	return headlessServicePrefix + clusterName
@@ -21,8 +21,14 @@
 package k8sops
 
 import (
+	"strings"
 	"testing"
 
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -30,3 +36,37 @@ func TestCommon(t *testing.T) {
 	ssName := StatefulSetName("testCluster", 1)
 	require.Equal(t, "testCluster-rep1", ssName)
 }
+
+func TestStatefulSetNameForGroup(t *testing.T) {
+	for _, test := range []struct {
+		group string
+		exp   string
+	}{
+		{group: "us-east1-b", exp: "testCluster-us-east1-b"},
+		{group: "US_East1.b", exp: "testCluster-us-east1-b"},
+		{group: "_rack1_", exp: "testCluster-rack1"},
+	} {
+		assert.Equal(t, test.exp, StatefulSetNameForGroup("testCluster", test.group))
+	}
+}
+
+func TestValidateStatefulSetNameForGroup(t *testing.T) {
+	cluster := &myspec.M3DBCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "testCluster"},
+		Spec: myspec.ClusterSpec{
+			IsolationGroups: []myspec.IsolationGroup{
+				{Name: "us-east-1a"},
+				{Name: "us_east_1a"},
+				{Name: "us-east-1b"},
+				{Name: strings.Repeat("a", 41)},
+				{Name: strings.Repeat("b", 40)},
+			},
+		},
+	}
+
+	assert.Error(t, ValidateStatefulSetNameForGroup(cluster, "us-east-1a"))
+	assert.Error(t, ValidateStatefulSetNameForGroup(cluster, "us_east_1a"))
+	assert.NoError(t, ValidateStatefulSetNameForGroup(cluster, "us-east-1b"))
+	assert.Error(t, ValidateStatefulSetNameForGroup(cluster, strings.Repeat("a", 41)))
+	assert.NoError(t, ValidateStatefulSetNameForGroup(cluster, strings.Repeat("b", 40)))
+}
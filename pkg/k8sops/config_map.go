@@ -22,6 +22,8 @@ package k8sops
 
 import (
 	"errors"
+	"hash/fnv"
+	"strconv"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 
@@ -76,6 +78,29 @@ func defaultConfigMapName(clusterName string) string {
 	return "m3db-config-map-" + clusterName
 }
 
+// ConfigMapName returns the name of the ConfigMap mounted by a cluster's pods:
+// either the one specified in the cluster spec or the default one.
+func ConfigMapName(cluster *myspec.M3DBCluster) (string, error) {
+	cmName := defaultConfigMapName(cluster.Name)
+	if cluster.Spec.ConfigMapName != nil {
+		cmName = *cluster.Spec.ConfigMapName
+	}
+
+	if cmName == "" {
+		return "", errEmptyConfigMapName
+	}
+
+	return cmName, nil
+}
+
+// ConfigMapHash returns a hash of the m3 configuration file contained in a
+// ConfigMap.
+func ConfigMapHash(cm *corev1.ConfigMap) string {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(cm.Data[_configurationFileName]))
+	return strconv.FormatUint(uint64(hasher.Sum32()), 16)
+}
+
 // Build the volume for the pod and the volumeMount for the container containing
 // necessary config map info. If a user specified a configMap of their own we'll
 // mount it, otherwise we mount the default one (the controller is expected to
@@ -86,13 +111,9 @@ func buildConfigMapComponents(cluster *myspec.M3DBCluster) (corev1.Volume, corev
 		MountPath: _configurationDirectory,
 	}
 
-	cmName := defaultConfigMapName(cluster.Name)
-	if cluster.Spec.ConfigMapName != nil {
-		cmName = *cluster.Spec.ConfigMapName
-	}
-
-	if cmName == "" {
-		return corev1.Volume{}, corev1.VolumeMount{}, errEmptyConfigMapName
+	cmName, err := ConfigMapName(cluster)
+	if err != nil {
+		return corev1.Volume{}, corev1.VolumeMount{}, err
 	}
 
 	vol := corev1.Volume{
@@ -118,3 +118,37 @@ func TestBuildConfigMapComponents(t *testing.T) {
 	_, _, err = buildConfigMapComponents(cluster)
 	assert.Equal(t, errEmptyConfigMapName, err)
 }
+
+func TestConfigMapName(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+
+	name, err := ConfigMapName(cluster)
+	assert.NoError(t, err)
+	assert.Equal(t, "m3db-config-map-m3db-cluster", name)
+
+	cluster.Spec.ConfigMapName = pointer.StringPtr("foo")
+	name, err = ConfigMapName(cluster)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", name)
+
+	cluster.Spec.ConfigMapName = pointer.StringPtr("")
+	_, err = ConfigMapName(cluster)
+	assert.Equal(t, errEmptyConfigMapName, err)
+}
+
+func TestConfigMapHash(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		Data: map[string]string{
+			"m3.yml": "config_a",
+		},
+	}
+
+	hash := ConfigMapHash(cm)
+	assert.Equal(t, hash, ConfigMapHash(cm.DeepCopy()))
+
+	cm.Data["unrelated"] = "foo"
+	assert.Equal(t, hash, ConfigMapHash(cm))
+
+	cm.Data["m3.yml"] = "config_b"
+	assert.NotEqual(t, hash, ConfigMapHash(cm))
+}
@@ -22,6 +22,7 @@ package k8sops
 
 import (
 	"fmt"
+	"reflect"
 	"time"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
@@ -70,14 +71,18 @@ func (k *k8sops) GetCRD(name string) (*apiextensionsv1beta1.CustomResourceDefini
 	return crd, nil
 }
 
-// CreateCRD checks if M3DB CRD exists. If not, create
+// CreateCRD checks if the M3DB CRD with the given name exists. If not, create
 func (k *k8sops) CreateCRD(name string) error {
+	expected := k.generateCRDByName(name)
+	if expected == nil {
+		return fmt.Errorf("unknown CRD %s", name)
+	}
+
 	crd, err := k.GetCRD(name)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			k.logger.Debug("crd is missing, creating it")
-			crdObject := k.GenerateCRD()
-			crd, err := k.kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions().Create(crdObject)
+			k.logger.Debug("crd is missing, creating it", zap.String("name", name))
+			crd, err := k.kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions().Create(expected)
 			if err != nil {
 				panic(err)
 			}
@@ -103,23 +108,64 @@ func (k *k8sops) CreateCRD(name string) error {
 			})
 
 			if err != nil {
-				deleteErr := k.kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions().Delete(myspec.Name, nil)
+				deleteErr := k.kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions().Delete(name, nil)
 				if deleteErr != nil {
 					return errors.NewAggregate([]error{err, deleteErr})
 				}
 				return err
 			}
 
-			k.logger.Info("CRD created")
+			k.logger.Info("CRD created", zap.String("name", name))
 		} else {
 			panic(err)
 		}
 	} else {
 		k.logger.Info("CRD already exists", zap.String("name", crd.ObjectMeta.Name))
+		return k.ensureCRDSpec(crd, expected)
 	}
 	return nil
 }
 
+// generateCRDByName returns the CRD the operator creates with the given name,
+// or nil if there's none.
+func (k *k8sops) generateCRDByName(name string) *apiextensionsv1beta1.CustomResourceDefinition {
+	switch name {
+	case myspec.Name:
+		return k.GenerateCRD()
+	case myspec.NamespaceName:
+		return k.GenerateNamespaceCRD()
+	}
+	return nil
+}
+
+// ensureCRDSpec updates the short names, categories, subresources, validation
+// schema and printer columns of a CRD created by an older version of the
+// operator to those of the expected CRD. The rest of the CRD's names are
+// defaulted by the API server and left as they are.
+func (k *k8sops) ensureCRDSpec(crd, expected *apiextensionsv1beta1.CustomResourceDefinition) error {
+	if reflect.DeepEqual(crd.Spec.Names.ShortNames, expected.Spec.Names.ShortNames) &&
+		reflect.DeepEqual(crd.Spec.Names.Categories, expected.Spec.Names.Categories) &&
+		reflect.DeepEqual(crd.Spec.Subresources, expected.Spec.Subresources) &&
+		reflect.DeepEqual(crd.Spec.Validation, expected.Spec.Validation) &&
+		reflect.DeepEqual(crd.Spec.AdditionalPrinterColumns, expected.Spec.AdditionalPrinterColumns) {
+		return nil
+	}
+
+	crd = crd.DeepCopy()
+	crd.Spec.Names.ShortNames = expected.Spec.Names.ShortNames
+	crd.Spec.Names.Categories = expected.Spec.Names.Categories
+	crd.Spec.Subresources = expected.Spec.Subresources
+	crd.Spec.Validation = expected.Spec.Validation
+	crd.Spec.AdditionalPrinterColumns = expected.Spec.AdditionalPrinterColumns
+	if _, err := k.kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions().Update(crd); err != nil {
+		k.logger.Error("could not update CRD spec", zap.Error(err))
+		return err
+	}
+
+	k.logger.Info("updated CRD spec", zap.String("name", crd.ObjectMeta.Name))
+	return nil
+}
+
 // UpdateCRD will update a CRD
 func (k *k8sops) UpdateCRD(cluster *myspecv1.M3DBCluster) (*myspecv1.M3DBCluster, error) {
 	updated, err := k.crdClient.OperatorV1alpha1().M3DBClusters(cluster.GetNamespace()).Update(cluster)
@@ -0,0 +1,236 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package k8sops
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// schemaConstraints add the constraints that can't be derived from the Go
+// types, such as required fields, enums and bounds, to the schema of the field
+// at a given path of a resource.
+type schemaConstraints map[string]func(s *apiextensionsv1beta1.JSONSchemaProps)
+
+var clusterSchemaConstraints = schemaConstraints{
+	"spec": func(s *apiextensionsv1beta1.JSONSchemaProps) {
+		s.Required = []string{"replicationFactor", "isolationGroups"}
+	},
+	"spec.replicationFactor": minimum(1),
+	"spec.numberOfShards":    minimum(1),
+	"spec.isolationGroups": func(s *apiextensionsv1beta1.JSONSchemaProps) {
+		s.MinItems = int64Ptr(1)
+	},
+	"spec.replicas": minimum(0),
+	"spec.isolationGroups[]": func(s *apiextensionsv1beta1.JSONSchemaProps) {
+		// numInstances isn't required since groups with no instances are
+		// encoded without it.
+		s.Required = []string{"name"}
+	},
+	"spec.isolationGroups[].name": func(s *apiextensionsv1beta1.JSONSchemaProps) {
+		// Group names are used as label values.
+		s.MinLength = int64Ptr(1)
+		s.MaxLength = int64Ptr(63)
+	},
+	"spec.isolationGroups[].numInstances": minimum(0),
+	"spec.namespaces[]": func(s *apiextensionsv1beta1.JSONSchemaProps) {
+		s.Required = []string{"name"}
+	},
+	"spec.namespaces[].name": func(s *apiextensionsv1beta1.JSONSchemaProps) {
+		s.MinLength = int64Ptr(1)
+	},
+	"spec.namespaces[].preset": enum(
+		string(namespace.PresetTenSecondsTwoDaysIndexed),
+		string(namespace.PresetOneMinuteFourtyDaysIndexed),
+	),
+	"spec.podIdentityConfig.sources[]": enum(
+		string(myspec.PodIdentitySourcePodUID),
+		string(myspec.PodIdentitySourceNodeSpecExternalID),
+		string(myspec.PodIdentitySourceNodeSpecProviderID),
+		string(myspec.PodIdentitySourceNodeName),
+	),
+	"spec.podIdentityConfig.changePolicy": enum(
+		string(myspec.PodIdentityChangePolicyIgnore),
+		string(myspec.PodIdentityChangePolicyReplace),
+	),
+	"spec.namespaceDeletionPolicy": enum(
+		string(myspec.NamespaceDeletionPolicyRetain),
+		string(myspec.NamespaceDeletionPolicyDelete),
+	),
+	"spec.namespaceDeletionGracePeriodSeconds":      minimum(0),
+	"spec.scalingPolicy.maxInstancesRemovedPerStep": minimum(1),
+	"spec.bootstrapTimeouts.degradedAfterSeconds":   minimum(1),
+	"spec.bootstrapTimeouts.redAfterSeconds":        minimum(1),
+}
+
+var namespaceSchemaConstraints = schemaConstraints{
+	"spec": func(s *apiextensionsv1beta1.JSONSchemaProps) {
+		s.Required = []string{"clusterName"}
+	},
+	"spec.clusterName": func(s *apiextensionsv1beta1.JSONSchemaProps) {
+		s.MinLength = int64Ptr(1)
+	},
+	"spec.preset": enum(
+		string(namespace.PresetTenSecondsTwoDaysIndexed),
+		string(namespace.PresetOneMinuteFourtyDaysIndexed),
+	),
+}
+
+// clusterValidation returns the OpenAPI v3 schema that M3DBClusters are
+// validated against. The schema of the spec is generated from the ClusterSpec
+// type. The status is only written by the operator and isn't validated.
+func clusterValidation() *apiextensionsv1beta1.CustomResourceValidation {
+	return &apiextensionsv1beta1.CustomResourceValidation{
+		OpenAPIV3Schema: &apiextensionsv1beta1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+				"spec": schemaForType(reflect.TypeOf(myspec.ClusterSpec{}), "spec", clusterSchemaConstraints),
+			},
+		},
+	}
+}
+
+// namespaceValidation returns the OpenAPI v3 schema that M3DBNamespaces are
+// validated against. Like clusterValidation, only the spec is validated.
+func namespaceValidation() *apiextensionsv1beta1.CustomResourceValidation {
+	return &apiextensionsv1beta1.CustomResourceValidation{
+		OpenAPIV3Schema: &apiextensionsv1beta1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+				"spec": schemaForType(reflect.TypeOf(myspec.NamespaceSpec{}), "spec", namespaceSchemaConstraints),
+			},
+		},
+	}
+}
+
+// schemaForType returns the schema of the JSON encoding of a type, at the given
+// path of a resource with the given constraints. Structs defined outside of the
+// operator's API package, e.g. those of the core Kubernetes API, are only
+// described as objects since the API server validates them where they're used.
+func schemaForType(t reflect.Type, path string, constraints schemaConstraints) apiextensionsv1beta1.JSONSchemaProps {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var s apiextensionsv1beta1.JSONSchemaProps
+	switch t.Kind() {
+	case reflect.String:
+		s.Type = "string"
+	case reflect.Bool:
+		s.Type = "boolean"
+	case reflect.Int32, reflect.Uint32:
+		s.Type = "integer"
+		s.Format = "int32"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		s.Type = "integer"
+		s.Format = "int64"
+	case reflect.Float32, reflect.Float64:
+		s.Type = "number"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			s.Type = "string"
+			s.Format = "byte"
+			break
+		}
+		items := schemaForType(t.Elem(), path+"[]", constraints)
+		s.Type = "array"
+		s.Items = &apiextensionsv1beta1.JSONSchemaPropsOrArray{Schema: &items}
+	case reflect.Map:
+		values := schemaForType(t.Elem(), path+"{}", constraints)
+		s.Type = "object"
+		s.AdditionalProperties = &apiextensionsv1beta1.JSONSchemaPropsOrBool{
+			Allows: true,
+			Schema: &values,
+		}
+	case reflect.Struct:
+		s.Type = "object"
+		if t.PkgPath() == reflect.TypeOf(myspec.ClusterSpec{}).PkgPath() {
+			s.Properties = map[string]apiextensionsv1beta1.JSONSchemaProps{}
+			addFieldSchemas(s.Properties, t, path, constraints)
+		}
+	}
+
+	if constrain, ok := constraints[path]; ok {
+		constrain(&s)
+	}
+	return s
+}
+
+// addFieldSchemas adds the schemas of a struct's JSON encoded fields to props.
+func addFieldSchemas(
+	props map[string]apiextensionsv1beta1.JSONSchemaProps,
+	t reflect.Type,
+	path string,
+	constraints schemaConstraints,
+) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// Unexported.
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			addFieldSchemas(props, ft, path, constraints)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		props[name] = schemaForType(field.Type, fmt.Sprintf("%s.%s", path, name), constraints)
+	}
+}
+
+func minimum(min float64) func(s *apiextensionsv1beta1.JSONSchemaProps) {
+	return func(s *apiextensionsv1beta1.JSONSchemaProps) {
+		s.Minimum = &min
+	}
+}
+
+func enum(values ...string) func(s *apiextensionsv1beta1.JSONSchemaProps) {
+	return func(s *apiextensionsv1beta1.JSONSchemaProps) {
+		for _, v := range values {
+			raw, _ := json.Marshal(v)
+			s.Enum = append(s.Enum, apiextensionsv1beta1.JSON{Raw: raw})
+		}
+	}
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
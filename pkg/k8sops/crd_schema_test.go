@@ -0,0 +1,171 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package k8sops
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enumValues(s apiextensionsv1beta1.JSONSchemaProps) []string {
+	var values []string
+	for _, v := range s.Enum {
+		values = append(values, string(v.Raw))
+	}
+	return values
+}
+
+func TestClusterValidation(t *testing.T) {
+	validation := clusterValidation()
+	require.NotNil(t, validation.OpenAPIV3Schema)
+
+	spec, ok := validation.OpenAPIV3Schema.Properties["spec"]
+	require.True(t, ok)
+	assert.Equal(t, "object", spec.Type)
+	assert.Equal(t, []string{"replicationFactor", "isolationGroups"}, spec.Required)
+
+	rf := spec.Properties["replicationFactor"]
+	assert.Equal(t, "integer", rf.Type)
+	assert.Equal(t, "int32", rf.Format)
+	require.NotNil(t, rf.Minimum)
+	assert.Equal(t, float64(1), *rf.Minimum)
+
+	groups := spec.Properties["isolationGroups"]
+	assert.Equal(t, "array", groups.Type)
+	require.NotNil(t, groups.Items)
+	group := groups.Items.Schema
+	require.NotNil(t, group)
+	assert.Equal(t, []string{"name"}, group.Required)
+	require.NotNil(t, group.Properties["name"].MaxLength)
+	assert.Equal(t, int64(63), *group.Properties["name"].MaxLength)
+
+	ns := spec.Properties["namespaces"].Items.Schema
+	require.NotNil(t, ns)
+	assert.Equal(t, []string{`"10s:2d"`, `"1m:40d"`}, enumValues(ns.Properties["preset"]))
+
+	retention := ns.Properties["options"].Properties["retentionOptions"]
+	assert.Equal(t, "object", retention.Type)
+	assert.Equal(t, "integer", retention.Properties["retentionPeriod"].Type)
+	assert.Equal(t, "int64", retention.Properties["retentionPeriod"].Format)
+	assert.Equal(t, "boolean", retention.Properties["blockDataExpiry"].Type)
+
+	sources := spec.Properties["podIdentityConfig"].Properties["sources"]
+	assert.Equal(t, "array", sources.Type)
+	assert.Equal(t, []string{`"PodUID"`, `"NodeSpecExternalID"`, `"NodeSpecProviderID"`, `"NodeName"`},
+		enumValues(*sources.Items.Schema))
+
+	labels := spec.Properties["labels"]
+	assert.Equal(t, "object", labels.Type)
+	require.NotNil(t, labels.AdditionalProperties)
+	assert.Equal(t, "string", labels.AdditionalProperties.Schema.Type)
+
+	// Core types are left to the API server to validate.
+	resources := spec.Properties["containerResources"]
+	assert.Equal(t, "object", resources.Type)
+	assert.Nil(t, resources.Properties)
+	assert.Equal(t, "object", spec.Properties["dataDirVolumeClaimTemplate"].Type)
+
+	_, hasStatus := validation.OpenAPIV3Schema.Properties["status"]
+	assert.False(t, hasStatus)
+}
+
+// missingRequired returns the paths of the required fields that are missing
+// from a decoded JSON value.
+func missingRequired(s apiextensionsv1beta1.JSONSchemaProps, value interface{}, path string) []string {
+	var missing []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				missing = append(missing, path+"."+name)
+			}
+		}
+		for name, field := range v {
+			if props, ok := s.Properties[name]; ok {
+				missing = append(missing, missingRequired(props, field, path+"."+name)...)
+			}
+		}
+	case []interface{}:
+		if s.Items == nil || s.Items.Schema == nil {
+			break
+		}
+		for i, item := range v {
+			missing = append(missing, missingRequired(*s.Items.Schema, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return missing
+}
+
+func TestClusterValidationRoundTrip(t *testing.T) {
+	// The operator writes back clusters as they're encoded by the typed
+	// client, so they must still match the schema. In particular, fields that
+	// are omitted when empty can't be required.
+	cluster := &myspec.M3DBCluster{
+		Spec: myspec.ClusterSpec{
+			ReplicationFactor: 3,
+			IsolationGroups: []myspec.IsolationGroup{
+				{Name: "a", NumInstances: 1},
+				{Name: "b", NumInstances: 1},
+				{Name: "c", NumInstances: 0},
+			},
+			Namespaces: []myspec.Namespace{
+				{Name: "default", Preset: "10s:2d"},
+			},
+		},
+	}
+
+	data, err := json.Marshal(cluster)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	schema := clusterValidation().OpenAPIV3Schema
+	assert.Empty(t, missingRequired(*schema, decoded, ""))
+}
+
+func TestNamespaceValidation(t *testing.T) {
+	validation := namespaceValidation()
+	require.NotNil(t, validation.OpenAPIV3Schema)
+
+	spec, ok := validation.OpenAPIV3Schema.Properties["spec"]
+	require.True(t, ok)
+	assert.Equal(t, []string{"clusterName"}, spec.Required)
+
+	clusterName := spec.Properties["clusterName"]
+	assert.Equal(t, "string", clusterName.Type)
+	require.NotNil(t, clusterName.MinLength)
+	assert.Equal(t, int64(1), *clusterName.MinLength)
+
+	assert.Equal(t, []string{`"10s:2d"`, `"1m:40d"`}, enumValues(spec.Properties["preset"]))
+	assert.Equal(t, "boolean", spec.Properties["options"].Properties["repairEnabled"].Type)
+
+	// Constraints of the cluster's schema don't apply to namespaces.
+	_, hasGroups := spec.Properties["isolationGroups"]
+	assert.False(t, hasGroups)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"testing"
+
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	kubeExtFake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEnsureCRDSubresources(t *testing.T) {
+	k := &k8sops{logger: zap.NewNop()}
+	oldCRD := k.GenerateCRD()
+	oldCRD.Spec.Subresources = nil
+
+	kubeExt := kubeExtFake.NewSimpleClientset(oldCRD)
+	k.kubeExt = kubeExt
+
+	err := k.CreateCRD(m3dboperator.Name)
+	require.NoError(t, err)
+
+	crd, err := kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions().Get(m3dboperator.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, crd.Spec.Subresources)
+	assert.Equal(t, &apiextensionsv1beta1.CustomResourceSubresourceStatus{}, crd.Spec.Subresources.Status)
+	require.NotNil(t, crd.Spec.Subresources.Scale)
+	assert.Equal(t, ".spec.replicas", crd.Spec.Subresources.Scale.SpecReplicasPath)
+}
+
+func TestEnsureCRDValidation(t *testing.T) {
+	k := &k8sops{logger: zap.NewNop()}
+	oldCRD := k.GenerateCRD()
+	oldCRD.Spec.Validation = nil
+
+	kubeExt := kubeExtFake.NewSimpleClientset(oldCRD)
+	k.kubeExt = kubeExt
+
+	err := k.CreateCRD(m3dboperator.Name)
+	require.NoError(t, err)
+
+	crd, err := kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions().Get(m3dboperator.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, clusterValidation(), crd.Spec.Validation)
+}
+
+func TestEnsureCRDNamesAndColumns(t *testing.T) {
+	k := &k8sops{logger: zap.NewNop()}
+	oldCRD := k.GenerateCRD()
+	oldCRD.Spec.Names.ShortNames = nil
+	oldCRD.Spec.Names.Categories = nil
+	oldCRD.Spec.Names.Singular = "m3dbcluster"
+	oldCRD.Spec.AdditionalPrinterColumns = nil
+
+	kubeExt := kubeExtFake.NewSimpleClientset(oldCRD)
+	k.kubeExt = kubeExt
+
+	err := k.CreateCRD(m3dboperator.Name)
+	require.NoError(t, err)
+
+	crd, err := kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions().Get(m3dboperator.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m3db"}, crd.Spec.Names.ShortNames)
+	assert.Equal(t, []string{"all"}, crd.Spec.Names.Categories)
+	assert.Equal(t, "m3dbcluster", crd.Spec.Names.Singular)
+	assert.Len(t, crd.Spec.AdditionalPrinterColumns, 6)
+}
+
+func TestEnsureNamespaceCRD(t *testing.T) {
+	k := &k8sops{logger: zap.NewNop()}
+	oldCRD := k.GenerateNamespaceCRD()
+	oldCRD.Spec.Validation = nil
+	oldCRD.Spec.AdditionalPrinterColumns = nil
+
+	kubeExt := kubeExtFake.NewSimpleClientset(oldCRD)
+	k.kubeExt = kubeExt
+
+	require.NoError(t, k.CreateCRD(m3dboperator.NamespaceName))
+
+	crd, err := kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions().Get(m3dboperator.NamespaceName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, namespaceValidation(), crd.Spec.Validation)
+	assert.Len(t, crd.Spec.AdditionalPrinterColumns, 4)
+}
+
+func TestCreateUnknownCRD(t *testing.T) {
+	k := &k8sops{logger: zap.NewNop(), kubeExt: kubeExtFake.NewSimpleClientset()}
+	assert.Error(t, k.CreateCRD("foo.operator.m3db.io"))
+}
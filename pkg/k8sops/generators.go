@@ -26,6 +26,7 @@ import (
 
 	m3dboperator "github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
 	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -81,6 +82,7 @@ var baseCoordinatorPorts = [...]m3dbPort{
 
 // GenerateCRD generates the crd object needed for the M3DBCluster
 func (k *k8sops) GenerateCRD() *apiextensionsv1beta1.CustomResourceDefinition {
+	labelSelectorPath := ".status.labelSelector"
 	return &apiextensionsv1beta1.CustomResourceDefinition{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: m3dboperator.Name,
@@ -90,35 +92,186 @@ func (k *k8sops) GenerateCRD() *apiextensionsv1beta1.CustomResourceDefinition {
 			Version: m3dboperator.Version,
 			Scope:   apiextensionsv1beta1.NamespaceScoped,
 			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
-				Plural: m3dboperator.ResourcePlural,
-				Kind:   m3dboperator.ResourceKind,
+				Plural:     m3dboperator.ResourcePlural,
+				Kind:       m3dboperator.ResourceKind,
+				ShortNames: []string{m3dboperator.ResourceShortName},
+				Categories: []string{m3dboperator.ResourceCategory},
 			},
+			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+				Scale: &apiextensionsv1beta1.CustomResourceSubresourceScale{
+					SpecReplicasPath:   ".spec.replicas",
+					StatusReplicasPath: ".status.instances",
+					LabelSelectorPath:  &labelSelectorPath,
+				},
+			},
+			Validation:               clusterValidation(),
+			AdditionalPrinterColumns: clusterPrinterColumns(),
+		},
+	}
+}
+
+// clusterPrinterColumns returns the columns kubectl shows when getting
+// M3DBClusters.
+func clusterPrinterColumns() []apiextensionsv1beta1.CustomResourceColumnDefinition {
+	return []apiextensionsv1beta1.CustomResourceColumnDefinition{
+		{
+			Name:        "State",
+			Type:        "string",
+			Description: "Health of the cluster",
+			JSONPath:    ".status.state",
+		},
+		{
+			Name:        "RF",
+			Type:        "integer",
+			Description: "Replication factor of the cluster",
+			JSONPath:    ".spec.replicationFactor",
+		},
+		{
+			Name:        "Shards",
+			Type:        "integer",
+			Description: "Number of shards in the cluster's placement",
+			JSONPath:    ".spec.numberOfShards",
+		},
+		{
+			Name:        "Instances",
+			Type:        "integer",
+			Description: "Total number of M3DB pods in the cluster",
+			JSONPath:    ".status.instances",
+		},
+		{
+			Name:        "Image",
+			Type:        "string",
+			Description: "M3DB image used by the cluster",
+			JSONPath:    ".spec.image",
+		},
+		{
+			Name:     "Age",
+			Type:     "date",
+			JSONPath: ".metadata.creationTimestamp",
+		},
+	}
+}
+
+// GenerateNamespaceCRD generates the crd object needed for the M3DBNamespace
+func (k *k8sops) GenerateNamespaceCRD() *apiextensionsv1beta1.CustomResourceDefinition {
+	return &apiextensionsv1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: m3dboperator.NamespaceName,
+		},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group:   m3dboperator.GroupName,
+			Version: m3dboperator.Version,
+			Versions: []apiextensionsv1beta1.CustomResourceDefinitionVersion{
+				{
+					Name:    m3dboperator.Version,
+					Served:  true,
+					Storage: true,
+				},
+			},
+			Scope: apiextensionsv1beta1.NamespaceScoped,
+			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+				Plural:     m3dboperator.NamespaceResourcePlural,
+				Kind:       m3dboperator.NamespaceResourceKind,
+				ShortNames: []string{m3dboperator.NamespaceResourceShortName},
+				Categories: []string{m3dboperator.ResourceCategory},
+			},
+			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+			},
+			Validation:               namespaceValidation(),
+			AdditionalPrinterColumns: namespacePrinterColumns(),
+		},
+	}
+}
+
+// namespacePrinterColumns returns the columns kubectl shows when getting
+// M3DBNamespaces.
+func namespacePrinterColumns() []apiextensionsv1beta1.CustomResourceColumnDefinition {
+	return []apiextensionsv1beta1.CustomResourceColumnDefinition{
+		{
+			Name:        "Cluster",
+			Type:        "string",
+			Description: "Cluster the namespace is created in",
+			JSONPath:    ".spec.clusterName",
+		},
+		{
+			Name:        "Ready",
+			Type:        "string",
+			Description: "Whether the namespace exists in the cluster with the options in its spec",
+			JSONPath:    `.status.conditions[?(@.type=="Ready")].status`,
+		},
+		{
+			Name:        "Reason",
+			Type:        "string",
+			Description: "Reason the namespace is or isn't ready",
+			JSONPath:    `.status.conditions[?(@.type=="Ready")].reason`,
+		},
+		{
+			Name:     "Age",
+			Type:     "date",
+			JSONPath: ".metadata.creationTimestamp",
 		},
 	}
 }
 
+// StatefulSetOption configures optional parts of a generated StatefulSet.
+type StatefulSetOption interface {
+	execute(*statefulSetOptions)
+}
+
+type statefulSetOptions struct {
+	configHash string
+	name       string
+}
+
+type statefulSetOptionFn func(o *statefulSetOptions)
+
+func (fn statefulSetOptionFn) execute(o *statefulSetOptions) {
+	fn(o)
+}
+
+// WithConfigHash sets the hash of the cluster's m3 configuration on the
+// generated pod template, so that a change in configuration results in a
+// change to the template.
+func WithConfigHash(hash string) StatefulSetOption {
+	return statefulSetOptionFn(func(o *statefulSetOptions) {
+		o.configHash = hash
+	})
+}
+
+// WithStatefulSetName overrides the name of the generated StatefulSet, which
+// otherwise is derived from the cluster and isolation group names. It's used
+// to preserve the names of existing sets.
+func WithStatefulSetName(name string) StatefulSetOption {
+	return statefulSetOptionFn(func(o *statefulSetOptions) {
+		o.name = name
+	})
+}
+
 // GenerateStatefulSet provides a statefulset object for a m3db cluster
 func GenerateStatefulSet(
 	cluster *myspec.M3DBCluster,
 	isolationGroup string,
 	instanceAmount int32,
+	opts ...StatefulSetOption,
 ) (*appsv1.StatefulSet, error) {
-	// TODO(schallert): always sort zones alphabetically.
-
-	stsID := -1
-	for i, g := range cluster.Spec.IsolationGroups {
-		if g.Name == isolationGroup {
-			stsID = i
-			break
-		}
+	setOpts := &statefulSetOptions{}
+	for _, o := range opts {
+		o.execute(setOpts)
 	}
 
-	if stsID == -1 {
+	if _, ok := myspec.IsolationGroups(cluster.Spec.IsolationGroups).GetByName(isolationGroup); !ok {
 		return nil, fmt.Errorf("could not find isogroup '%s' in spec", isolationGroup)
 	}
 
-	clusterName := cluster.GetName()
-	ssName := StatefulSetName(clusterName, stsID)
+	ssName := setOpts.name
+	if ssName == "" {
+		if err := ValidateStatefulSetNameForGroup(cluster, isolationGroup); err != nil {
+			return nil, err
+		}
+		ssName = StatefulSetNameForGroup(cluster.GetName(), isolationGroup)
+	}
 
 	clusterSpec := cluster.Spec
 
@@ -185,6 +338,22 @@ func GenerateStatefulSet(
 		statefulSet.Spec.VolumeClaimTemplates = []v1.PersistentVolumeClaim{*template}
 	}
 
+	if setOpts.configHash != "" {
+		statefulSet.Spec.Template.Annotations = map[string]string{
+			annotations.ConfigHash: setOpts.configHash,
+		}
+	}
+
+	// Record the hash of the template so the controller can detect when the
+	// set's template no longer matches the cluster spec.
+	templateHash, err := PodTemplateHash(statefulSet.Spec.Template)
+	if err != nil {
+		return nil, err
+	}
+	statefulSet.Annotations = map[string]string{
+		annotations.PodTemplateHash: templateHash,
+	}
+
 	return statefulSet, nil
 }
 
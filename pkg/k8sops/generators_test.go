@@ -50,9 +50,21 @@ func TestGenerateCRD(t *testing.T) {
 			Version: m3dboperator.Version,
 			Scope:   apiextensionsv1beta1.NamespaceScoped,
 			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
-				Plural: m3dboperator.ResourcePlural,
-				Kind:   m3dboperator.ResourceKind,
+				Plural:     m3dboperator.ResourcePlural,
+				Kind:       m3dboperator.ResourceKind,
+				ShortNames: []string{"m3db"},
+				Categories: []string{"all"},
 			},
+			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+				Scale: &apiextensionsv1beta1.CustomResourceSubresourceScale{
+					SpecReplicasPath:   ".spec.replicas",
+					StatusReplicasPath: ".status.instances",
+					LabelSelectorPath:  pointer.StringPtr(".status.labelSelector"),
+				},
+			},
+			Validation:               clusterValidation(),
+			AdditionalPrinterColumns: clusterPrinterColumns(),
 		},
 	}
 
@@ -60,6 +72,53 @@ func TestGenerateCRD(t *testing.T) {
 	require.Nil(t, err)
 	newCRD := k.GenerateCRD()
 	require.Equal(t, crd, newCRD)
+
+	var paths []string
+	for _, col := range newCRD.Spec.AdditionalPrinterColumns {
+		paths = append(paths, col.JSONPath)
+	}
+	assert.Equal(t, []string{
+		".status.state",
+		".spec.replicationFactor",
+		".spec.numberOfShards",
+		".status.instances",
+		".spec.image",
+		".metadata.creationTimestamp",
+	}, paths)
+}
+
+func TestGenerateNamespaceCRD(t *testing.T) {
+	k, err := newFakeK8sops()
+	require.Nil(t, err)
+
+	crd := k.GenerateNamespaceCRD()
+	assert.Equal(t, m3dboperator.NamespaceName, crd.Name)
+	assert.Equal(t, "m3dbnamespaces.operator.m3db.io", crd.Name)
+	assert.Equal(t, m3dboperator.GroupName, crd.Spec.Group)
+	assert.Equal(t, []apiextensionsv1beta1.CustomResourceDefinitionVersion{
+		{Name: "v1alpha1", Served: true, Storage: true},
+	}, crd.Spec.Versions)
+	assert.Equal(t, apiextensionsv1beta1.CustomResourceDefinitionNames{
+		Plural:     "m3dbnamespaces",
+		Kind:       "M3DBNamespace",
+		ShortNames: []string{"m3dbns"},
+		Categories: []string{"all"},
+	}, crd.Spec.Names)
+	require.NotNil(t, crd.Spec.Subresources)
+	assert.NotNil(t, crd.Spec.Subresources.Status)
+	assert.Nil(t, crd.Spec.Subresources.Scale)
+	assert.Equal(t, namespaceValidation(), crd.Spec.Validation)
+
+	var paths []string
+	for _, col := range crd.Spec.AdditionalPrinterColumns {
+		paths = append(paths, col.JSONPath)
+	}
+	assert.Equal(t, []string{
+		".spec.clusterName",
+		`.status.conditions[?(@.type=="Ready")].status`,
+		`.status.conditions[?(@.type=="Ready")].reason`,
+		".metadata.creationTimestamp",
+	}, paths)
 }
 
 func TestGenerateStatefulSet(t *testing.T) {
@@ -69,7 +128,7 @@ func TestGenerateStatefulSet(t *testing.T) {
 	instanceAmount := &fixture.Spec.IsolationGroups[0].NumInstances
 	clusterName := fixture.GetName()
 
-	ssName := StatefulSetName(clusterName, 0)
+	ssName := StatefulSetNameForGroup(clusterName, isolationGroup)
 
 	health := &v1.Probe{
 		TimeoutSeconds:      _probeTimeoutSeconds,
@@ -111,13 +170,16 @@ func TestGenerateStatefulSet(t *testing.T) {
 				*metav1.NewControllerRef(fixture, schema.GroupVersionKind{
 					Group:   myspec.SchemeGroupVersion.Group,
 					Version: myspec.SchemeGroupVersion.Version,
-					Kind:    "m3dbcluster",
+					Kind:    m3dboperator.ResourceKind,
 				}),
 			},
 		},
 		Spec: appsv1.StatefulSetSpec{
 			ServiceName:         "m3dbnode-m3db-cluster",
 			PodManagementPolicy: "Parallel",
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.OnDeleteStatefulSetStrategyType,
+			},
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
@@ -264,8 +326,17 @@ func TestGenerateStatefulSet(t *testing.T) {
 		},
 	}
 
+	setTemplateHash := func(ss *appsv1.StatefulSet) {
+		hash, err := PodTemplateHash(ss.Spec.Template)
+		require.NoError(t, err)
+		ss.Annotations = map[string]string{
+			"operator.m3db.io/pod-template-hash": hash,
+		}
+	}
+
 	// Base config stateful set
 	ss := baseSS.DeepCopy()
+	setTemplateHash(ss)
 	newSS, err := GenerateStatefulSet(fixture, isolationGroup, *instanceAmount)
 	assert.NoError(t, err)
 	assert.NotNil(t, newSS)
@@ -276,6 +347,7 @@ func TestGenerateStatefulSet(t *testing.T) {
 	fixture = getFixture("testM3DBCluster.yaml", t)
 	fixture.Spec.ConfigMapName = pointer.StringPtr("mymap")
 	ss.Spec.Template.Spec.Volumes[2].VolumeSource.ConfigMap.Name = "mymap"
+	setTemplateHash(ss)
 	newSS, err = GenerateStatefulSet(fixture, isolationGroup, *instanceAmount)
 	assert.NoError(t, err)
 	assert.NotNil(t, newSS)
@@ -292,11 +364,65 @@ func TestGenerateStatefulSet(t *testing.T) {
 			EmptyDir: &v1.EmptyDirVolumeSource{},
 		},
 	})
+	setTemplateHash(ss)
 
 	newSS, err = GenerateStatefulSet(fixture, isolationGroup, *instanceAmount)
 	assert.NoError(t, err)
 	assert.NotNil(t, newSS)
 	assert.Equal(t, ss, newSS)
+
+	// Reset spec and fixture, test config hash
+	ss = baseSS.DeepCopy()
+	fixture = getFixture("testM3DBCluster.yaml", t)
+	ss.Spec.Template.Annotations = map[string]string{
+		"operator.m3db.io/config-hash": "abc",
+	}
+	setTemplateHash(ss)
+
+	newSS, err = GenerateStatefulSet(fixture, isolationGroup, *instanceAmount, WithConfigHash("abc"))
+	assert.NoError(t, err)
+	assert.NotNil(t, newSS)
+	assert.Equal(t, ss, newSS)
+}
+
+func TestGenerateStatefulSetWithName(t *testing.T) {
+	fixture := getFixture("testM3DBCluster.yaml", t)
+	group := fixture.Spec.IsolationGroups[0]
+
+	set, err := GenerateStatefulSet(fixture, group.Name, group.NumInstances)
+	require.NoError(t, err)
+	assert.Equal(t, "m3db-cluster-us-fake1-a", set.Name)
+
+	legacy, err := GenerateStatefulSet(fixture, group.Name, group.NumInstances,
+		WithStatefulSetName("m3db-cluster-rep0"))
+	require.NoError(t, err)
+	assert.Equal(t, "m3db-cluster-rep0", legacy.Name)
+	assert.Equal(t, "m3db-cluster-rep0", legacy.Spec.Template.Spec.Containers[0].Name)
+
+	_, err = GenerateStatefulSet(fixture, "us-fake1-z", group.NumInstances)
+	assert.Error(t, err)
+}
+
+func TestPodTemplateHash(t *testing.T) {
+	fixture := getFixture("testM3DBCluster.yaml", t)
+	group := fixture.Spec.IsolationGroups[0]
+
+	set, err := GenerateStatefulSet(fixture, group.Name, group.NumInstances)
+	require.NoError(t, err)
+
+	hash, err := PodTemplateHash(set.Spec.Template)
+	require.NoError(t, err)
+	assert.Equal(t, hash, set.Annotations["operator.m3db.io/pod-template-hash"])
+
+	// Replica count is not part of the template.
+	resized, err := GenerateStatefulSet(fixture, group.Name, group.NumInstances+1)
+	require.NoError(t, err)
+	assert.Equal(t, hash, resized.Annotations["operator.m3db.io/pod-template-hash"])
+
+	fixture.Spec.Image = "m3db/m3dbnode:new"
+	updated, err := GenerateStatefulSet(fixture, group.Name, group.NumInstances)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, updated.Annotations["operator.m3db.io/pod-template-hash"])
 }
 
 func TestGenerateM3DBService(t *testing.T) {
@@ -134,6 +134,20 @@ func (mr *MockK8sopsMockRecorder) GenerateCRD() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateCRD", reflect.TypeOf((*MockK8sops)(nil).GenerateCRD))
 }
 
+// GenerateNamespaceCRD mocks base method
+func (m *MockK8sops) GenerateNamespaceCRD() *v1beta1.CustomResourceDefinition {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateNamespaceCRD")
+	ret0, _ := ret[0].(*v1beta1.CustomResourceDefinition)
+	return ret0
+}
+
+// GenerateNamespaceCRD indicates an expected call of GenerateNamespaceCRD
+func (mr *MockK8sopsMockRecorder) GenerateNamespaceCRD() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateNamespaceCRD", reflect.TypeOf((*MockK8sops)(nil).GenerateNamespaceCRD))
+}
+
 // UpdateCRD mocks base method
 func (m *MockK8sops) UpdateCRD(cluster *v1alpha1.M3DBCluster) (*v1alpha1.M3DBCluster, error) {
 	m.ctrl.T.Helper()
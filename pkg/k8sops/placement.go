@@ -33,9 +33,22 @@ import (
 )
 
 const (
+	// DefaultNumberOfShards is the number of shards a cluster's placement is
+	// initialized with if its spec doesn't set one.
+	DefaultNumberOfShards = 256
+
 	_zoneEmbedded = "embedded"
 )
 
+// NumberOfShards returns the number of shards a cluster's placement is
+// initialized with.
+func NumberOfShards(cluster *myspec.M3DBCluster) int32 {
+	if cluster.Spec.NumberOfShards == 0 {
+		return DefaultNumberOfShards
+	}
+	return cluster.Spec.NumberOfShards
+}
+
 // PlacementInstanceFromPod creates a new m3cluster placement instance given a
 // pod spec.
 func PlacementInstanceFromPod(cluster *myspec.M3DBCluster, pod *corev1.Pod, idProvider podidentity.Provider) (*placementpb.Instance, error) {
@@ -72,3 +72,11 @@ func TestPlacementInstanceFromPod(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expInst, inst)
 }
+
+func TestNumberOfShards(t *testing.T) {
+	cluster := &myspec.M3DBCluster{}
+	assert.Equal(t, int32(DefaultNumberOfShards), NumberOfShards(cluster))
+
+	cluster.Spec.NumberOfShards = 64
+	assert.Equal(t, int32(64), NumberOfShards(cluster))
+}
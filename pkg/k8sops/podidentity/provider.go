@@ -45,6 +45,14 @@ var (
 	errEmptyNodeProviderID = errors.New("node provider ID cannot be empty with source == prodiverID")
 )
 
+// DefaultSources returns the sources a pod's identity is derived from when a
+// cluster has no PodIdentityConfig.
+func DefaultSources() []myspec.PodIdentitySource {
+	return []myspec.PodIdentitySource{
+		myspec.PodIdentitySourcePodUID,
+	}
+}
+
 // Provider creates a pod's cluster identity given required info.
 type Provider interface {
 	Identity(pod *corev1.Pod, cluster *myspec.M3DBCluster) (*myspec.PodIdentity, error)
@@ -80,9 +88,7 @@ func (p *provider) Identity(pod *corev1.Pod, cluster *myspec.M3DBCluster) (*mysp
 	config := cluster.Spec.PodIdentityConfig
 	if config == nil {
 		config = &myspec.PodIdentityConfig{
-			Sources: []myspec.PodIdentitySource{
-				myspec.PodIdentitySourcePodUID,
-			},
+			Sources: DefaultSources(),
 		}
 	}
 
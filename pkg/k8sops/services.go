@@ -42,7 +42,6 @@ import (
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"go.uber.org/zap"
 )
@@ -67,12 +66,7 @@ func (k *k8sops) EnsureService(cluster *myspec.M3DBCluster, svc *v1.Service) err
 	_, err := k.GetService(cluster, svc.Name)
 	if errors.IsNotFound(err) {
 		k.logger.Info("service doesn't exist, creating it", zap.String("service", svc.Name))
-		selfRef := metav1.NewControllerRef(cluster, schema.GroupVersionKind{
-			Group:   myspec.SchemeGroupVersion.Group,
-			Version: myspec.SchemeGroupVersion.Version,
-			Kind:    "m3dbcluster",
-		})
-		svc.SetOwnerReferences([]metav1.OwnerReference{*selfRef})
+		svc.SetOwnerReferences([]metav1.OwnerReference{*GenerateOwnerRef(cluster)})
 		if _, err := k.kclient.CoreV1().Services(cluster.GetNamespace()).Create(svc); err != nil {
 			return err
 		}
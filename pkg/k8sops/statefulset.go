@@ -21,11 +21,15 @@
 package k8sops
 
 import (
+	"encoding/json"
 	errorz "errors"
 	"fmt"
+	"hash/fnv"
+	"strconv"
 	"strings"
 	"time"
 
+	m3dboperator "github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
@@ -41,6 +45,9 @@ import (
 )
 
 const (
+	// DefaultImage is the M3DB image used by clusters that don't set one.
+	DefaultImage = "quay.io/m3db/m3dbnode:latest"
+
 	// FailureDomainZoneKey is the standard Kubernetes node label for a zone.
 	FailureDomainZoneKey = "failure-domain.beta.kubernetes.io/zone"
 
@@ -214,6 +221,9 @@ func NewBaseStatefulSet(ssName, isolationGroup string, cluster *myspec.M3DBClust
 
 	clusterName := cluster.Name
 	image := cluster.Spec.Image
+	if image == "" {
+		image = DefaultImage
+	}
 
 	objLabels := labels.BaseLabels(cluster)
 	objLabels[labels.IsolationGroup] = isolationGroup
@@ -231,6 +241,11 @@ func NewBaseStatefulSet(ssName, isolationGroup string, cluster *myspec.M3DBClust
 		Spec: appsv1.StatefulSetSpec{
 			ServiceName:         HeadlessServiceName(clusterName),
 			PodManagementPolicy: "Parallel",
+			// Pods are restarted by the controller one at a time once the
+			// placement is healthy, rather than by the StatefulSet controller.
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.OnDeleteStatefulSetStrategyType,
+			},
 			Selector: &metav1.LabelSelector{
 				MatchLabels: objLabels,
 			},
@@ -354,12 +369,24 @@ func GenerateZoneAffinity(zone string) *v1.Affinity {
 	}
 }
 
+// PodTemplateHash returns a hash of a pod template, used to detect when the
+// template of a StatefulSet differs from the one generated for its cluster.
+func PodTemplateHash(template v1.PodTemplateSpec) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+
+	hasher := fnv.New32a()
+	hasher.Write(data)
+	return strconv.FormatUint(uint64(hasher.Sum32()), 16), nil
+}
+
 // GenerateOwnerRef generates an owner reference to a given m3db cluster.
 func GenerateOwnerRef(cluster *myspec.M3DBCluster) *metav1.OwnerReference {
 	return metav1.NewControllerRef(cluster, schema.GroupVersionKind{
 		Group:   myspec.SchemeGroupVersion.Group,
 		Version: myspec.SchemeGroupVersion.Version,
-		// TODO(schallert): use a const here
-		Kind: "m3dbcluster",
+		Kind:    m3dboperator.ResourceKind,
 	})
 }
@@ -42,12 +42,16 @@ type K8sops interface {
 	// GetCRD will get a CRD
 	GetCRD(name string) (*apiextensionsv1beta1.CustomResourceDefinition, error)
 
-	// CreateCRD checks if M3DB CRD exists. If not, create
+	// CreateCRD checks if the M3DB CRD with the given name exists. If not,
+	// create
 	CreateCRD(name string) error
 
 	// GenerateCRD generates the crd object needed for the M3DBCluster
 	GenerateCRD() *apiextensionsv1beta1.CustomResourceDefinition
 
+	// GenerateNamespaceCRD generates the crd object needed for the M3DBNamespace
+	GenerateNamespaceCRD() *apiextensionsv1beta1.CustomResourceDefinition
+
 	// UpdateCRD will update a CRD
 	UpdateCRD(cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, error)
 
@@ -141,6 +141,23 @@ func (n *namespaceClient) List() (*admin.NamespaceGetResponse, error) {
 	return data, nil
 }
 
+// Update will update a namespace. The coordinator only allows a namespace's
+// retention period to be updated, so any other options in the request must
+// match the namespace's current options.
+func (n *namespaceClient) Update(req *admin.NamespaceAddRequest) error {
+	url := n.url + namespaceBaseURL
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	_, err = n.client.DoHTTPRequest("PUT", url, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	n.logger.Info("successfully updated namespace", zap.String("namespace", req.Name))
+	return nil
+}
+
 // Delete will delete a namespace
 func (n *namespaceClient) Delete(namespace string) error {
 	url := fmt.Sprintf(n.url+namespaceDeleteFmt, namespace)
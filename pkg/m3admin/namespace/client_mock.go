@@ -97,3 +97,17 @@ func (mr *MockClientMockRecorder) Delete(namespace interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), namespace)
 }
+
+// Update mocks base method
+func (m *MockClient) Update(request *admin.NamespaceAddRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update
+func (mr *MockClientMockRecorder) Update(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), request)
+}
@@ -131,6 +131,48 @@ func TestGetErr(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+func TestUpdate(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() != "/api/v1/namespace" || r.Method != "PUT" {
+			w.WriteHeader(404)
+			return
+		}
+
+		bytes, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		const exp = `{"name":"foo","options":{"bootstrapEnabled":true}}`
+		assert.Equal(t, exp, string(bytes))
+
+		w.WriteHeader(200)
+		w.Write([]byte("{}"))
+	}))
+
+	defer s.Close()
+	client := newNamespaceClient(t, s.URL)
+
+	err := client.Update(&admin.NamespaceAddRequest{
+		Name: "foo",
+		Options: &ns.NamespaceOptions{
+			BootstrapEnabled: true,
+		},
+	})
+	require.Nil(t, err)
+}
+
+func TestUpdateErr(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		w.Write([]byte("{}"))
+	}))
+
+	defer s.Close()
+	client := newNamespaceClient(t, s.URL)
+
+	err := client.Update(&admin.NamespaceAddRequest{Name: "foo"})
+	require.NotNil(t, err)
+}
+
 func TestDelete(t *testing.T) {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.String() != "/api/v1/namespace/default" || r.Method != "DELETE" {
@@ -98,3 +98,77 @@ func indexOptsFromAPI(opts myspec.IndexOptions) *m3ns.IndexOptions {
 		BlockSizeNanos: opts.BlockSize.Nanoseconds(),
 	}
 }
+
+type optionField struct {
+	name    string
+	mutable bool
+	value   func(opts *m3ns.NamespaceOptions) interface{}
+}
+
+// optionFields enumerates the namespace options set by the operator. The
+// coordinator's namespace update endpoint only allows a namespace's retention
+// period to be changed, so every other field is immutable once the namespace
+// has been created.
+var optionFields = []optionField{
+	{name: "bootstrapEnabled", value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetBootstrapEnabled() }},
+	{name: "flushEnabled", value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetFlushEnabled() }},
+	{name: "writesToCommitLog", value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetWritesToCommitLog() }},
+	{name: "cleanupEnabled", value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetCleanupEnabled() }},
+	{name: "repairEnabled", value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetRepairEnabled() }},
+	{name: "snapshotEnabled", value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetSnapshotEnabled() }},
+	{
+		name:    "retentionOptions.retentionPeriod",
+		mutable: true,
+		value:   func(o *m3ns.NamespaceOptions) interface{} { return o.GetRetentionOptions().GetRetentionPeriodNanos() },
+	},
+	{
+		name:  "retentionOptions.blockSize",
+		value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetRetentionOptions().GetBlockSizeNanos() },
+	},
+	{
+		name:  "retentionOptions.bufferFuture",
+		value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetRetentionOptions().GetBufferFutureNanos() },
+	},
+	{
+		name:  "retentionOptions.bufferPast",
+		value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetRetentionOptions().GetBufferPastNanos() },
+	},
+	{
+		name:  "retentionOptions.blockDataExpiry",
+		value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetRetentionOptions().GetBlockDataExpiry() },
+	},
+	{
+		name: "retentionOptions.blockDataExpiryAfterNotAccessPeriod",
+		value: func(o *m3ns.NamespaceOptions) interface{} {
+			return o.GetRetentionOptions().GetBlockDataExpiryAfterNotAccessPeriodNanos()
+		},
+	},
+	{
+		name:  "indexOptions.enabled",
+		value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetIndexOptions().GetEnabled() },
+	},
+	{
+		name:  "indexOptions.blockSize",
+		value: func(o *m3ns.NamespaceOptions) interface{} { return o.GetIndexOptions().GetBlockSizeNanos() },
+	},
+}
+
+// OptionsDiff compares the options of an existing namespace with the desired
+// options, returning the names of the fields that differ. Fields that can be
+// updated in place are returned in changed, and those that can't are returned
+// in immutable.
+func OptionsDiff(current, desired *m3ns.NamespaceOptions) (changed, immutable []string) {
+	for _, field := range optionFields {
+		if field.value(current) == field.value(desired) {
+			continue
+		}
+
+		if field.mutable {
+			changed = append(changed, field.name)
+		} else {
+			immutable = append(immutable, field.name)
+		}
+	}
+
+	return changed, immutable
+}
@@ -152,3 +152,33 @@ func TestIndexOptsFromAPI(t *testing.T) {
 	assert.True(t, iOpts.Enabled)
 	assert.Equal(t, int64(1000000000), iOpts.BlockSizeNanos)
 }
+
+func TestOptionsDiff(t *testing.T) {
+	current := requestOptsFromAPI(&presetTenSecondsTwoDaysIndexed)
+
+	changed, immutable := OptionsDiff(current, requestOptsFromAPI(&presetTenSecondsTwoDaysIndexed))
+	assert.Empty(t, changed)
+	assert.Empty(t, immutable)
+
+	opts := presetTenSecondsTwoDaysIndexed
+	opts.RetentionOptions.RetentionPeriod = 7 * 24 * time.Hour
+	changed, immutable = OptionsDiff(current, requestOptsFromAPI(&opts))
+	assert.Equal(t, []string{"retentionOptions.retentionPeriod"}, changed)
+	assert.Empty(t, immutable)
+
+	// Only the retention period can be updated.
+	opts.RepairEnabled = true
+	opts.RetentionOptions.BlockSize = 4 * time.Hour
+	opts.IndexOptions.Enabled = false
+	changed, immutable = OptionsDiff(current, requestOptsFromAPI(&opts))
+	assert.Equal(t, []string{"retentionOptions.retentionPeriod"}, changed)
+	assert.Equal(t, []string{"repairEnabled", "retentionOptions.blockSize", "indexOptions.enabled"}, immutable)
+
+	// Options unset in the registry are treated as zero values.
+	changed, immutable = OptionsDiff(&m3ns.NamespaceOptions{}, &m3ns.NamespaceOptions{
+		RetentionOptions: &m3ns.RetentionOptions{},
+		IndexOptions:     &m3ns.IndexOptions{},
+	})
+	assert.Empty(t, changed)
+	assert.Empty(t, immutable)
+}
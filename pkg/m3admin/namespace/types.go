@@ -33,4 +33,7 @@ type Client interface {
 	List() (*admin.NamespaceGetResponse, error)
 	// Delete will delete a namespace given a name
 	Delete(namespace string) error
+	// Update will update the options of an existing namespace. The request has
+	// the same form as a create request.
+	Update(request *admin.NamespaceAddRequest) error
 }
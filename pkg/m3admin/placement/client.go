@@ -42,9 +42,17 @@ const (
 	placementBaseURL    = "/api/v1/services/m3db/placement"
 	placementInitURL    = placementBaseURL + "/init"
 	placementReplaceURL = placementBaseURL + "/replace"
+	placementRemoveURL  = placementBaseURL + "/remove"
 	placementRemoveFmt  = placementBaseURL + "/%s"
 )
 
+// removeRequest is the body of a request to the coordinator's placement remove
+// endpoint, which removes all of the given instances in a single placement
+// change.
+type removeRequest struct {
+	InstanceIDs []string `json:"instanceIds"`
+}
+
 type placementClient struct {
 	url    string
 	client m3admin.Client
@@ -117,9 +125,16 @@ func (p *placementClient) Get() (m3placement.Placement, error) {
 
 // Add will add an instance to the current placement
 func (p *placementClient) Add(instance placementpb.Instance) error {
+	return p.AddMany([]placementpb.Instance{instance})
+}
+
+func (p *placementClient) AddMany(instances []placementpb.Instance) error {
 	url := p.url + placementBaseURL
 	request := &admin.PlacementAddRequest{
-		Instances: []*placementpb.Instance{&instance},
+		Instances: make([]*placementpb.Instance, 0, len(instances)),
+	}
+	for i := range instances {
+		request.Instances = append(request.Instances, &instances[i])
 	}
 	data, err := json.Marshal(request)
 	if err != nil {
@@ -129,7 +144,7 @@ func (p *placementClient) Add(instance placementpb.Instance) error {
 	if err != nil {
 		return err
 	}
-	p.logger.Info("successfully add instance to placement")
+	p.logger.Info("successfully added instances to placement", zap.Int("instances", len(instances)))
 	return nil
 }
 
@@ -139,6 +154,20 @@ func (p *placementClient) Remove(id string) error {
 	return err
 }
 
+func (p *placementClient) RemoveMany(ids []string) error {
+	url := p.url + placementRemoveURL
+	data, err := json.Marshal(&removeRequest{InstanceIDs: ids})
+	if err != nil {
+		return err
+	}
+	_, err = p.client.DoHTTPRequest(http.MethodPost, url, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	p.logger.Info("successfully removed instances from placement", zap.Strings("instances", ids))
+	return nil
+}
+
 func (p *placementClient) Replace(leavingInstanceID string, newInst placementpb.Instance) error {
 	url := p.url + placementReplaceURL
 
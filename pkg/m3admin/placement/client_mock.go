@@ -114,6 +114,20 @@ func (mr *MockClientMockRecorder) Add(instance interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockClient)(nil).Add), instance)
 }
 
+// AddMany mocks base method
+func (m *MockClient) AddMany(instances []placementpb.Instance) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMany", instances)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMany indicates an expected call of AddMany
+func (mr *MockClientMockRecorder) AddMany(instances interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMany", reflect.TypeOf((*MockClient)(nil).AddMany), instances)
+}
+
 // Remove mocks base method
 func (m *MockClient) Remove(id string) error {
 	m.ctrl.T.Helper()
@@ -128,6 +142,20 @@ func (mr *MockClientMockRecorder) Remove(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockClient)(nil).Remove), id)
 }
 
+// RemoveMany mocks base method
+func (m *MockClient) RemoveMany(ids []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMany", ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMany indicates an expected call of RemoveMany
+func (mr *MockClientMockRecorder) RemoveMany(ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMany", reflect.TypeOf((*MockClient)(nil).RemoveMany), ids)
+}
+
 // Replace mocks base method
 func (m *MockClient) Replace(leavingInstanceID string, newInstance placementpb.Instance) error {
 	m.ctrl.T.Helper()
@@ -21,6 +21,7 @@
 package placement
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -117,6 +118,28 @@ func TestAddErr(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+func TestAddMany(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bytes, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+
+		const expected = `{"instances":[{"id":"a"},{"id":"b"}]}`
+		assert.Equal(t, expected, string(bytes))
+
+		w.WriteHeader(200)
+		w.Write([]byte("{}"))
+	}))
+
+	defer s.Close()
+	client := newPlacementClient(t, s.URL)
+
+	err := client.AddMany([]placementpb.Instance{{Id: "a"}, {Id: "b"}})
+	require.Nil(t, err)
+}
+
 func TestInit(t *testing.T) {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
@@ -186,6 +209,32 @@ func TestRemove(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestRemoveMany(t *testing.T) {
+	var reqs []removeRequest
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() != "/api/v1/services/m3db/placement/remove" || r.Method != http.MethodPost {
+			w.WriteHeader(404)
+			return
+		}
+		var req removeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		reqs = append(reqs, req)
+		w.WriteHeader(200)
+		w.Write([]byte(`{"placement": {}}`))
+	}))
+	defer s.Close()
+
+	client := newPlacementClient(t, s.URL)
+	err := client.RemoveMany([]string{"instFoo", "instBar"})
+	assert.NoError(t, err)
+
+	// All instances are removed in one request.
+	assert.Equal(t, []removeRequest{{InstanceIDs: []string{"instFoo", "instBar"}}}, reqs)
+}
+
 func TestReplace(t *testing.T) {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.String() != "/api/v1/services/m3db/placement/replace" || r.Method != http.MethodPost {
@@ -36,8 +36,14 @@ type Client interface {
 	Delete() error
 	// Add will add an instance to the placement
 	Add(instance placementpb.Instance) error
+	// AddMany will add several instances to the placement in a single
+	// operation.
+	AddMany(instances []placementpb.Instance) error
 	// Remove removes a given instance with the given ID from the placement.
 	Remove(id string) error
+	// RemoveMany removes several instances from the placement in a single
+	// placement change.
+	RemoveMany(ids []string) error
 	// Replace replaces one instance with another.
 	Replace(leavingInstanceID string, newInstance placementpb.Instance) error
 }
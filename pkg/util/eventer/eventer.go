@@ -61,6 +61,10 @@ const (
 	ReasonFailSync    = "FailedToSync"
 	ReasonSuccessSync = "SuccessfulSync"
 
+	// Adopt events
+	ReasonSuccessfulAdopt = "SuccessfulAdopt"
+	ReasonFailedToAdopt   = "FailedToAdopt"
+
 	// Misc events
 	ReasonLongerThanUsual = "TimeLongerThanUsual"
 	ReasonUnknown         = "Unknown"
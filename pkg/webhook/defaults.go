@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package webhook
+
+import (
+	"reflect"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+)
+
+// DefaultNamespacePreset is the preset used by namespaces that set neither a
+// preset nor options.
+const DefaultNamespacePreset = namespace.PresetTenSecondsTwoDaysIndexed
+
+// SetClusterDefaults fills in the fields of a cluster's spec that the operator
+// would otherwise interpret as defaults, so that the stored spec shows how the
+// cluster is managed. The number of shards is only defaulted until the
+// cluster's placement is initialized, as it can't be changed afterwards. The
+// replicas are defaulted so that the cluster's scale subresource reports its
+// size.
+//
+// ConfigMapName is left unset: the operator only creates and manages the
+// cluster's default ConfigMap if the spec doesn't name one.
+func SetClusterDefaults(cluster *myspec.M3DBCluster) {
+	spec := &cluster.Spec
+
+	if spec.Image == "" {
+		spec.Image = k8sops.DefaultImage
+	}
+
+	if spec.NumberOfShards == 0 && !cluster.Status.HasInitializedPlacement() {
+		spec.NumberOfShards = k8sops.DefaultNumberOfShards
+	}
+
+	if spec.PodIdentityConfig == nil {
+		spec.PodIdentityConfig = &myspec.PodIdentityConfig{
+			Sources: podidentity.DefaultSources(),
+		}
+	}
+	if spec.PodIdentityConfig.ChangePolicy == "" {
+		spec.PodIdentityConfig.ChangePolicy = myspec.PodIdentityChangePolicyIgnore
+	}
+
+	for i := range spec.Namespaces {
+		ns := &spec.Namespaces[i]
+		if ns.Preset == "" && ns.Options == nil {
+			ns.Preset = string(DefaultNamespacePreset)
+		}
+	}
+
+	defaultReplicas(spec)
+}
+
+// defaultReplicas sets the replicas of a spec that doesn't set them to the
+// number of instances in its isolation groups, so that the cluster's scale
+// subresource reports its size. Since the replicas are divided evenly between
+// the groups, they're only defaulted if the groups are already sized that way
+// so that defaulting never resizes a group.
+func defaultReplicas(spec *myspec.ClusterSpec) {
+	if spec.Replicas != nil || len(spec.IsolationGroups) == 0 {
+		return
+	}
+
+	var total int32
+	for _, group := range spec.IsolationGroups {
+		total += group.NumInstances
+	}
+
+	withReplicas := *spec
+	withReplicas.Replicas = &total
+	if !reflect.DeepEqual(withReplicas.DesiredIsolationGroups(), spec.IsolationGroups) {
+		return
+	}
+	spec.Replicas = &total
+}
+
+// resetReplicas clears the replicas of an updated cluster whose isolation
+// groups were resized without its replicas being changed, so that the new
+// group sizes take effect and the replicas are defaulted again from them.
+func resetReplicas(old, cluster *myspec.M3DBCluster) {
+	if reflect.DeepEqual(old.Spec.Replicas, cluster.Spec.Replicas) &&
+		groupsResized(old.Spec.IsolationGroups, cluster.Spec.IsolationGroups) {
+		cluster.Spec.Replicas = nil
+	}
+}
+
+// groupsResized returns true if isolation groups were added, removed or had
+// their number of instances changed.
+func groupsResized(old, groups []myspec.IsolationGroup) bool {
+	if len(old) != len(groups) {
+		return true
+	}
+	for i := range groups {
+		if old[i].Name != groups[i].Name || old[i].NumInstances != groups[i].NumInstances {
+			return true
+		}
+	}
+	return false
+}
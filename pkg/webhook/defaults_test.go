@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package webhook
+
+import (
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	"github.com/kubernetes/utils/pointer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetClusterDefaults(t *testing.T) {
+	cluster := newCluster()
+	cluster.Spec.NumberOfShards = 0
+	cluster.Spec.Namespaces = append(cluster.Spec.Namespaces,
+		myspec.Namespace{Name: "empty"},
+		myspec.Namespace{Name: "opts", Options: &myspec.NamespaceOptions{}},
+	)
+
+	SetClusterDefaults(cluster)
+
+	assert.Equal(t, "quay.io/m3db/m3dbnode:latest", cluster.Spec.Image)
+	assert.Equal(t, int32(256), cluster.Spec.NumberOfShards)
+	assert.Equal(t, &myspec.PodIdentityConfig{
+		Sources:      []myspec.PodIdentitySource{myspec.PodIdentitySourcePodUID},
+		ChangePolicy: myspec.PodIdentityChangePolicyIgnore,
+	}, cluster.Spec.PodIdentityConfig)
+	assert.Equal(t, "10s:2d", cluster.Spec.Namespaces[0].Preset)
+	assert.Equal(t, "10s:2d", cluster.Spec.Namespaces[1].Preset)
+	assert.Equal(t, "", cluster.Spec.Namespaces[2].Preset)
+	assert.Nil(t, cluster.Spec.ConfigMapName)
+	assert.Equal(t, pointer.Int32Ptr(3), cluster.Spec.Replicas)
+}
+
+func TestSetClusterDefaultsKeepsSetFields(t *testing.T) {
+	cluster := newCluster()
+	cluster.Spec.Image = "m3db/m3dbnode:foo"
+	cluster.Spec.NumberOfShards = 64
+	cluster.Spec.Replicas = pointer.Int32Ptr(6)
+	cluster.Spec.PodIdentityConfig = &myspec.PodIdentityConfig{
+		Sources:      []myspec.PodIdentitySource{myspec.PodIdentitySourceNodeName},
+		ChangePolicy: myspec.PodIdentityChangePolicyReplace,
+	}
+	expected := cluster.DeepCopy()
+
+	SetClusterDefaults(cluster)
+	assert.Equal(t, expected, cluster)
+}
+
+func TestSetClusterDefaultsInitializedPlacement(t *testing.T) {
+	cluster := initializedCluster()
+	cluster.Spec.NumberOfShards = 0
+
+	SetClusterDefaults(cluster)
+	assert.Equal(t, int32(0), cluster.Spec.NumberOfShards)
+}
+
+func TestDefaultReplicas(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		sizes       []int32
+		expReplicas *int32
+	}{
+		{
+			name:        "even",
+			sizes:       []int32{2, 2, 2},
+			expReplicas: pointer.Int32Ptr(6),
+		},
+		{
+			name:        "remainder in first groups",
+			sizes:       []int32{2, 1, 1},
+			expReplicas: pointer.Int32Ptr(4),
+		},
+		{
+			name:  "uneven",
+			sizes: []int32{1, 1, 2},
+		},
+		{
+			name:        "empty groups",
+			sizes:       []int32{1, 0, 0},
+			expReplicas: pointer.Int32Ptr(1),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			spec := newCluster().Spec
+			for i, size := range test.sizes {
+				spec.IsolationGroups[i].NumInstances = size
+			}
+
+			defaultReplicas(&spec)
+			assert.Equal(t, test.expReplicas, spec.Replicas)
+		})
+	}
+}
+
+func TestResetReplicas(t *testing.T) {
+	old := newCluster()
+	old.Spec.Replicas = pointer.Int32Ptr(3)
+
+	// Changing the replicas keeps them.
+	cluster := old.DeepCopy()
+	cluster.Spec.Replicas = pointer.Int32Ptr(6)
+	resetReplicas(old, cluster)
+	assert.Equal(t, pointer.Int32Ptr(6), cluster.Spec.Replicas)
+
+	// Resizing a group without changing the replicas clears them.
+	cluster = old.DeepCopy()
+	cluster.Spec.IsolationGroups[2].NumInstances = 2
+	resetReplicas(old, cluster)
+	assert.Nil(t, cluster.Spec.Replicas)
+
+	// Other changes keep them.
+	cluster = old.DeepCopy()
+	cluster.Spec.Image = "m3db/m3dbnode:foo"
+	resetReplicas(old, cluster)
+	assert.Equal(t, pointer.Int32Ptr(3), cluster.Spec.Replicas)
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ValidatePath is the path at which M3DBCluster changes are validated.
+	ValidatePath = "/validate-m3dbcluster"
+
+	// MutatePath is the path at which defaults are set on M3DBClusters.
+	MutatePath = "/mutate-m3dbcluster"
+
+	clusterKind = "M3DBCluster"
+)
+
+// Server serves the operator's admission webhooks.
+type Server struct {
+	logger *zap.Logger
+	mux    *http.ServeMux
+}
+
+// NewServer returns a new webhook server.
+func NewServer(opts ...Option) *Server {
+	o := &options{}
+	for _, opt := range opts {
+		opt.execute(o)
+	}
+
+	if o.logger == nil {
+		o.logger = zap.NewNop()
+	}
+
+	s := &Server{
+		logger: o.logger,
+		mux:    http.NewServeMux(),
+	}
+	s.mux.HandleFunc(ValidatePath, s.serveReview(s.validate))
+	s.mux.HandleFunc(MutatePath, s.serveReview(s.mutate))
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+type reviewFn func(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse
+
+// serveReview decodes an AdmissionReview from the request, passes it to fn,
+// and writes fn's response back.
+func (s *Server) serveReview(fn reviewFn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error reading request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		review := &admissionv1beta1.AdmissionReview{}
+		if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+			http.Error(w, "invalid admission review", http.StatusBadRequest)
+			return
+		}
+
+		resp := fn(review.Request)
+		resp.UID = review.Request.UID
+
+		data, err := json.Marshal(&admissionv1beta1.AdmissionReview{
+			TypeMeta: review.TypeMeta,
+			Response: resp,
+		})
+		if err != nil {
+			s.logger.Error("error marshaling admission review", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// validate admits creates and updates of M3DBClusters with valid specs.
+func (s *Server) validate(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Kind.Kind != clusterKind {
+		return allowed()
+	}
+
+	cluster := &myspec.M3DBCluster{}
+	if err := json.Unmarshal(req.Object.Raw, cluster); err != nil {
+		return errored(fmt.Errorf("error decoding cluster: %v", err))
+	}
+
+	errs := ValidateClusterFields(req.Object.Raw)
+	switch req.Operation {
+	case admissionv1beta1.Create:
+		errs = append(errs, ValidateCluster(cluster)...)
+	case admissionv1beta1.Update:
+		old := &myspec.M3DBCluster{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return errored(fmt.Errorf("error decoding old cluster: %v", err))
+		}
+		errs = append(errs, ValidateClusterUpdate(old, cluster)...)
+	}
+
+	if len(errs) == 0 {
+		return allowed()
+	}
+
+	s.logger.Info("rejected invalid cluster",
+		zap.String("namespace", req.Namespace),
+		zap.String("cluster", cluster.Name),
+		zap.String("operation", string(req.Operation)),
+		zap.Error(errs.ToAggregate()))
+
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: fmt.Sprintf("M3DBCluster %q is invalid: %v", cluster.Name, errs.ToAggregate()),
+		},
+	}
+}
+
+// patchOp is a single JSON patch operation.
+type patchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// mutate sets defaults on the specs of created and updated M3DBClusters.
+// Updates that don't change the spec, such as those made by the operator, are
+// admitted unchanged.
+func (s *Server) mutate(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Kind.Kind != clusterKind {
+		return allowed()
+	}
+
+	cluster := &myspec.M3DBCluster{}
+	if err := json.Unmarshal(req.Object.Raw, cluster); err != nil {
+		return errored(fmt.Errorf("error decoding cluster: %v", err))
+	}
+
+	if len(ValidateClusterFields(req.Object.Raw)) > 0 {
+		// Patching the spec would drop the unknown fields before they're
+		// rejected by the validating webhook.
+		return allowed()
+	}
+
+	defaulted := cluster.DeepCopy()
+	if req.Operation == admissionv1beta1.Update {
+		old := &myspec.M3DBCluster{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return errored(fmt.Errorf("error decoding old cluster: %v", err))
+		}
+		if reflect.DeepEqual(old.Spec, cluster.Spec) {
+			return allowed()
+		}
+		// Only the operator writes the status, so the stored one decides
+		// whether the placement is initialized.
+		defaulted.Status = old.Status
+		resetReplicas(old, defaulted)
+	}
+
+	SetClusterDefaults(defaulted)
+	if reflect.DeepEqual(defaulted.Spec, cluster.Spec) {
+		return allowed()
+	}
+
+	patch, err := json.Marshal([]patchOp{
+		{Op: "add", Path: "/spec", Value: defaulted.Spec},
+	})
+	if err != nil {
+		return errored(fmt.Errorf("error encoding patch: %v", err))
+	}
+
+	s.logger.Info("set cluster defaults",
+		zap.String("namespace", req.Namespace),
+		zap.String("cluster", cluster.Name),
+		zap.String("operation", string(req.Operation)))
+
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patch,
+		PatchType: &patchType,
+	}
+}
+
+func allowed() *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{Allowed: true}
+}
+
+func errored(err error) *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonBadRequest,
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		},
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rawCluster(t *testing.T, cluster *myspec.M3DBCluster) runtime.RawExtension {
+	data, err := json.Marshal(cluster)
+	require.NoError(t, err)
+	return runtime.RawExtension{Raw: data}
+}
+
+// withSpecField adds a field to the spec of an encoded cluster.
+func withSpecField(t *testing.T, raw runtime.RawExtension, name string, value interface{}) runtime.RawExtension {
+	var obj map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw.Raw, &obj))
+	obj["spec"].(map[string]interface{})[name] = value
+
+	data, err := json.Marshal(obj)
+	require.NoError(t, err)
+	return runtime.RawExtension{Raw: data}
+}
+
+func doReview(t *testing.T, server *Server, path string, req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	data, err := json.Marshal(&admissionv1beta1.AdmissionReview{Request: req})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	review := &admissionv1beta1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), review))
+	require.NotNil(t, review.Response)
+	assert.Equal(t, req.UID, review.Response.UID)
+	return review.Response
+}
+
+func TestServerValidate(t *testing.T) {
+	server := NewServer()
+
+	clusterKind := metav1.GroupVersionKind{Group: "operator.m3db.io", Version: "v1alpha1", Kind: "M3DBCluster"}
+
+	resp := doReview(t, server, ValidatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("create-valid"),
+		Kind:      clusterKind,
+		Operation: admissionv1beta1.Create,
+		Object:    rawCluster(t, newCluster()),
+	})
+	assert.True(t, resp.Allowed)
+
+	invalid := newCluster()
+	invalid.Spec.ReplicationFactor = 5
+	resp = doReview(t, server, ValidatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("create-invalid"),
+		Kind:      clusterKind,
+		Operation: admissionv1beta1.Create,
+		Object:    rawCluster(t, invalid),
+	})
+	assert.False(t, resp.Allowed)
+	require.NotNil(t, resp.Result)
+	assert.Equal(t, metav1.StatusReasonInvalid, resp.Result.Reason)
+	assert.Contains(t, resp.Result.Message, "spec.replicationFactor")
+
+	resp = doReview(t, server, ValidatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("create-unknown-field"),
+		Kind:      clusterKind,
+		Operation: admissionv1beta1.Create,
+		Object:    withSpecField(t, rawCluster(t, newCluster()), "replicationFactr", 3),
+	})
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, `unknown field "replicationFactr"`)
+
+	old := initializedCluster()
+	updated := old.DeepCopy()
+	updated.Spec.NumberOfShards = 1024
+	resp = doReview(t, server, ValidatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("update-shards"),
+		Kind:      clusterKind,
+		Operation: admissionv1beta1.Update,
+		Object:    rawCluster(t, updated),
+		OldObject: rawCluster(t, old),
+	})
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "spec.numberOfShards: Forbidden")
+
+	// Other kinds are always allowed.
+	resp = doReview(t, server, ValidatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("other"),
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Operation: admissionv1beta1.Create,
+	})
+	assert.True(t, resp.Allowed)
+}
+
+func decodePatchedSpec(t *testing.T, resp *admissionv1beta1.AdmissionResponse) myspec.ClusterSpec {
+	require.NotNil(t, resp.PatchType)
+	assert.Equal(t, admissionv1beta1.PatchTypeJSONPatch, *resp.PatchType)
+
+	var ops []struct {
+		Op    string             `json:"op"`
+		Path  string             `json:"path"`
+		Value myspec.ClusterSpec `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Patch, &ops))
+	require.Len(t, ops, 1)
+	assert.Equal(t, "add", ops[0].Op)
+	assert.Equal(t, "/spec", ops[0].Path)
+	return ops[0].Value
+}
+
+func TestServerMutate(t *testing.T) {
+	server := NewServer()
+
+	clusterKind := metav1.GroupVersionKind{Group: "operator.m3db.io", Version: "v1alpha1", Kind: "M3DBCluster"}
+
+	cluster := newCluster()
+	cluster.Spec.NumberOfShards = 0
+	resp := doReview(t, server, MutatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("create"),
+		Kind:      clusterKind,
+		Operation: admissionv1beta1.Create,
+		Object:    rawCluster(t, cluster),
+	})
+	assert.True(t, resp.Allowed)
+
+	expected := cluster.DeepCopy()
+	SetClusterDefaults(expected)
+	assert.Equal(t, expected.Spec, decodePatchedSpec(t, resp))
+
+	// Already defaulted specs aren't patched.
+	resp = doReview(t, server, MutatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("create-defaulted"),
+		Kind:      clusterKind,
+		Operation: admissionv1beta1.Create,
+		Object:    rawCluster(t, expected),
+	})
+	assert.True(t, resp.Allowed)
+	assert.Nil(t, resp.Patch)
+
+	// Specs with unknown fields aren't patched so that they're still rejected
+	// by validation.
+	resp = doReview(t, server, MutatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("create-unknown-field"),
+		Kind:      clusterKind,
+		Operation: admissionv1beta1.Create,
+		Object:    withSpecField(t, rawCluster(t, cluster), "numberOfShard", 64),
+	})
+	assert.True(t, resp.Allowed)
+	assert.Nil(t, resp.Patch)
+
+	// Updates that don't change the spec are admitted unchanged.
+	old := initializedCluster()
+	old.Spec.NumberOfShards = 0
+	updated := old.DeepCopy()
+	updated.Finalizers = []string{"foo"}
+	resp = doReview(t, server, MutatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("update-metadata"),
+		Kind:      clusterKind,
+		Operation: admissionv1beta1.Update,
+		Object:    rawCluster(t, updated),
+		OldObject: rawCluster(t, old),
+	})
+	assert.True(t, resp.Allowed)
+	assert.Nil(t, resp.Patch)
+
+	// Shards aren't defaulted once the stored cluster's placement is
+	// initialized.
+	updated = old.DeepCopy()
+	updated.Status = myspec.M3DBStatus{}
+	updated.Spec.IsolationGroups[0].NumInstances = 2
+	resp = doReview(t, server, MutatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("update-spec"),
+		Kind:      clusterKind,
+		Operation: admissionv1beta1.Update,
+		Object:    rawCluster(t, updated),
+		OldObject: rawCluster(t, old),
+	})
+	assert.True(t, resp.Allowed)
+	spec := decodePatchedSpec(t, resp)
+	assert.Equal(t, int32(0), spec.NumberOfShards)
+	assert.Equal(t, "quay.io/m3db/m3dbnode:latest", spec.Image)
+	assert.Equal(t, int32(4), *spec.Replicas)
+
+	// Resizing a group of a cluster with defaulted replicas resizes the
+	// cluster, leaving the replicas unset if the groups are no longer even.
+	old = initializedCluster()
+	SetClusterDefaults(old)
+	updated = old.DeepCopy()
+	updated.Spec.IsolationGroups[2].NumInstances = 2
+	resp = doReview(t, server, MutatePath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("update-groups"),
+		Kind:      clusterKind,
+		Operation: admissionv1beta1.Update,
+		Object:    rawCluster(t, updated),
+		OldObject: rawCluster(t, old),
+	})
+	assert.True(t, resp.Allowed)
+	spec = decodePatchedSpec(t, resp)
+	assert.Nil(t, spec.Replicas)
+	assert.Equal(t, int32(2), spec.IsolationGroups[2].NumInstances)
+}
+
+func TestServerBadRequest(t *testing.T) {
+	server := NewServer()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader([]byte("foo"))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ValidatePath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateCluster returns the errors in a cluster's spec that would prevent
+// the operator from creating or managing it.
+func ValidateCluster(cluster *myspec.M3DBCluster) field.ErrorList {
+	var (
+		errs     field.ErrorList
+		specPath = field.NewPath("spec")
+	)
+
+	numGroups := len(cluster.Spec.IsolationGroups)
+	if rf := cluster.Spec.ReplicationFactor; int(rf) > numGroups {
+		errs = append(errs, field.Invalid(specPath.Child("replicationFactor"), rf,
+			fmt.Sprintf("must not be greater than the number of isolation groups (%d)", numGroups)))
+	}
+
+	for i, group := range cluster.Spec.IsolationGroups {
+		if err := k8sops.ValidateStatefulSetNameForGroup(cluster, group.Name); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("isolationGroups").Index(i).Child("name"), group.Name, err.Error()))
+		}
+	}
+
+	for i, ns := range cluster.Spec.Namespaces {
+		if _, err := namespace.RequestFromSpec(ns); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("namespaces").Index(i), ns.Name, err.Error()))
+		}
+	}
+
+	return errs
+}
+
+// ValidateClusterFields returns an error if the spec of a JSON encoded cluster
+// has a field that isn't part of the ClusterSpec type, such as a misspelled
+// one. The CRD's schema can't reject them, and they'd otherwise be dropped
+// silently when the cluster is decoded. Only the spec is checked since the
+// metadata can have fields added by newer API servers.
+func ValidateClusterFields(raw []byte) field.ErrorList {
+	var obj struct {
+		Spec json.RawMessage `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil || len(obj.Spec) == 0 {
+		// Decoding errors are left to the caller.
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(obj.Spec))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&myspec.ClusterSpec{}); err != nil {
+		return field.ErrorList{field.Forbidden(field.NewPath("spec"), strings.TrimPrefix(err.Error(), "json: "))}
+	}
+	return nil
+}
+
+// ValidateClusterUpdate returns the errors in a change to a cluster's spec,
+// including changes to fields that can't be changed once the cluster's
+// placement is initialized. Updates that don't change the spec, such as those
+// made by the operator to a cluster's metadata, are always valid so that
+// clusters created before validation was enforced can still be managed.
+func ValidateClusterUpdate(old, cluster *myspec.M3DBCluster) field.ErrorList {
+	if reflect.DeepEqual(old.Spec, cluster.Spec) {
+		return nil
+	}
+
+	errs := ValidateCluster(cluster)
+
+	if !old.Status.HasInitializedPlacement() {
+		return errs
+	}
+
+	specPath := field.NewPath("spec")
+	if cluster.Spec.NumberOfShards != old.Spec.NumberOfShards {
+		errs = append(errs, field.Forbidden(specPath.Child("numberOfShards"),
+			"cannot be changed once the cluster's placement is initialized"))
+	}
+	if cluster.Spec.ReplicationFactor != old.Spec.ReplicationFactor {
+		errs = append(errs, field.Forbidden(specPath.Child("replicationFactor"),
+			"cannot be changed once the cluster's placement is initialized"))
+	}
+
+	return errs
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package webhook
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCluster() *myspec.M3DBCluster {
+	return &myspec.M3DBCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-a",
+			Namespace: "fake",
+		},
+		Spec: myspec.ClusterSpec{
+			ReplicationFactor: 3,
+			NumberOfShards:    256,
+			IsolationGroups: []myspec.IsolationGroup{
+				{Name: "a", NumInstances: 1},
+				{Name: "b", NumInstances: 1},
+				{Name: "c", NumInstances: 1},
+			},
+			Namespaces: []myspec.Namespace{
+				{Name: "metrics", Preset: "10s:2d"},
+			},
+		},
+	}
+}
+
+func initializedCluster() *myspec.M3DBCluster {
+	cluster := newCluster()
+	cluster.Status.UpdateCondition(myspec.ClusterCondition{
+		Type:   myspec.ClusterConditionPlacementInitialized,
+		Status: corev1.ConditionTrue,
+	})
+	return cluster
+}
+
+func TestValidateCluster(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		mutate  func(*myspec.M3DBCluster)
+		expErrs []string
+	}{
+		{
+			name:   "valid",
+			mutate: func(*myspec.M3DBCluster) {},
+		},
+		{
+			name: "rf greater than groups",
+			mutate: func(c *myspec.M3DBCluster) {
+				c.Spec.IsolationGroups = c.Spec.IsolationGroups[:2]
+			},
+			expErrs: []string{"spec.replicationFactor: Invalid value: 3: must not be greater than the number of isolation groups (2)"},
+		},
+		{
+			name: "colliding set names",
+			mutate: func(c *myspec.M3DBCluster) {
+				c.Spec.IsolationGroups[0].Name = "us_east_1a"
+				c.Spec.IsolationGroups[1].Name = "us-east-1a"
+			},
+			expErrs: []string{
+				`spec.isolationGroups[0].name: Invalid value: "us_east_1a": statefulset name 'cluster-a-us-east-1a' is the same as that of isolation group 'us-east-1a'`,
+				`spec.isolationGroups[1].name: Invalid value: "us-east-1a": statefulset name 'cluster-a-us-east-1a' is the same as that of isolation group 'us_east_1a'`,
+			},
+		},
+		{
+			name: "set name too long",
+			mutate: func(c *myspec.M3DBCluster) {
+				c.Spec.IsolationGroups[0].Name = strings.Repeat("a", 43)
+			},
+			expErrs: []string{fmt.Sprintf(`spec.isolationGroups[0].name: Invalid value: "%[1]s": statefulset name 'cluster-a-%[1]s' is longer than 52 characters`, strings.Repeat("a", 43))},
+		},
+		{
+			name: "preset and options",
+			mutate: func(c *myspec.M3DBCluster) {
+				c.Spec.Namespaces[0].Options = &myspec.NamespaceOptions{}
+			},
+			expErrs: []string{`spec.namespaces[0]: Invalid value: "metrics": must set only one of preset or options`},
+		},
+		{
+			name: "unknown preset",
+			mutate: func(c *myspec.M3DBCluster) {
+				c.Spec.Namespaces = append(c.Spec.Namespaces, myspec.Namespace{Name: "other", Preset: "foo"})
+			},
+			expErrs: []string{`spec.namespaces[1]: Invalid value: "other": preset 'foo' not found`},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := newCluster()
+			test.mutate(cluster)
+
+			var errs []string
+			for _, err := range ValidateCluster(cluster) {
+				errs = append(errs, err.Error())
+			}
+			assert.Equal(t, test.expErrs, errs)
+		})
+	}
+}
+
+func TestValidateClusterFields(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		raw     string
+		expErrs []string
+	}{
+		{
+			name: "known fields",
+			raw:  `{"metadata":{"name":"a","someNewField":1},"spec":{"replicationFactor":3,"isolationGroups":[{"name":"a","numInstances":0}]}}`,
+		},
+		{
+			name:    "unknown field",
+			raw:     `{"spec":{"replicationFactor":3,"isolationGroup":[]}}`,
+			expErrs: []string{`spec: Forbidden: unknown field "isolationGroup"`},
+		},
+		{
+			name:    "misspelled nested field",
+			raw:     `{"spec":{"isolationGroups":[{"name":"a","numInstance":1}]}}`,
+			expErrs: []string{`spec: Forbidden: unknown field "numInstance"`},
+		},
+		{
+			name: "no spec",
+			raw:  `{"metadata":{"name":"a"}}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var errs []string
+			for _, err := range ValidateClusterFields([]byte(test.raw)) {
+				errs = append(errs, err.Error())
+			}
+			assert.Equal(t, test.expErrs, errs)
+		})
+	}
+}
+
+func TestValidateClusterUpdate(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		old     *myspec.M3DBCluster
+		mutate  func(*myspec.M3DBCluster)
+		expErrs []string
+	}{
+		{
+			name: "uninitialized shards change",
+			old:  newCluster(),
+			mutate: func(c *myspec.M3DBCluster) {
+				c.Spec.NumberOfShards = 64
+			},
+		},
+		{
+			name: "initialized shards change",
+			old:  initializedCluster(),
+			mutate: func(c *myspec.M3DBCluster) {
+				c.Spec.NumberOfShards = 64
+			},
+			expErrs: []string{"spec.numberOfShards: Forbidden: cannot be changed once the cluster's placement is initialized"},
+		},
+		{
+			name: "initialized rf change",
+			old:  initializedCluster(),
+			mutate: func(c *myspec.M3DBCluster) {
+				c.Spec.ReplicationFactor = 1
+			},
+			expErrs: []string{"spec.replicationFactor: Forbidden: cannot be changed once the cluster's placement is initialized"},
+		},
+		{
+			name: "initialized instances change",
+			old:  initializedCluster(),
+			mutate: func(c *myspec.M3DBCluster) {
+				c.Spec.IsolationGroups[0].NumInstances = 2
+			},
+		},
+		{
+			name: "metadata change of invalid cluster",
+			old: func() *myspec.M3DBCluster {
+				c := initializedCluster()
+				c.Spec.ReplicationFactor = 5
+				return c
+			}(),
+			mutate: func(c *myspec.M3DBCluster) {
+				c.Finalizers = []string{"foo"}
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := test.old.DeepCopy()
+			test.mutate(cluster)
+
+			var errs []string
+			for _, err := range ValidateClusterUpdate(test.old, cluster) {
+				errs = append(errs, err.Error())
+			}
+			assert.Equal(t, test.expErrs, errs)
+		})
+	}
+}